@@ -0,0 +1,139 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package docker runs backend_types.Step definitions as docker containers
+// and, for StepTypeBuild steps, as buildx/BuildKit builds.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+// Runner invokes an external command, injectable so tests don't shell out.
+// stdin, if non-nil, is piped to the command, used for `docker login
+// --password-stdin` so a registry password never appears in argv.
+type Runner func(ctx context.Context, stdin io.Reader, name string, args ...string) error
+
+func execRunner(ctx context.Context, stdin io.Reader, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	return cmd.Run()
+}
+
+// RunBuild logs into every registry referenced by build.Auths and then runs
+// the build via `docker buildx build`. Logging in before the build matters
+// because the output and cache_from/cache_to legs of a build may each push
+// to or pull from a different registry than the one the step's image came
+// from, and buildx authenticates lazily at the point each ref is used.
+func RunBuild(ctx context.Context, run Runner, build *backend_types.Build) error {
+	if run == nil {
+		run = execRunner
+	}
+
+	for _, hostname := range sortedHostnames(build.Auths) {
+		if err := loginRegistry(ctx, run, hostname, build.Auths[hostname]); err != nil {
+			return fmt.Errorf("login to %q for build: %w", hostname, err)
+		}
+	}
+
+	return run(ctx, nil, "docker", append([]string{"buildx", "build"}, BuildArgs(build)...)...)
+}
+
+// BuildArgs translates a backend_types.Build into the `docker buildx build`
+// argument list.
+func BuildArgs(build *backend_types.Build) []string {
+	args := []string{"--file", build.Dockerfile}
+
+	if build.Target != "" {
+		args = append(args, "--target", build.Target)
+	}
+	for _, platform := range build.Platforms {
+		args = append(args, "--platform", platform)
+	}
+	for key, value := range build.BuildArgs {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", key, value))
+	}
+	for _, secret := range build.Secrets {
+		args = append(args, "--secret", secret)
+	}
+	for _, ssh := range build.SSH {
+		args = append(args, "--ssh", ssh)
+	}
+	for _, cacheFrom := range build.CacheFrom {
+		args = append(args, "--cache-from", formatBuildCache(cacheFrom))
+	}
+	for _, cacheTo := range build.CacheTo {
+		args = append(args, "--cache-to", formatBuildCache(cacheTo))
+	}
+	if output := formatBuildOutput(build.Output); output != "" {
+		args = append(args, "--output", output)
+	}
+
+	return append(args, build.Context)
+}
+
+func formatBuildCache(cache backend_types.BuildCache) string {
+	parts := []string{"type=" + cache.Type}
+	for key, value := range cache.Attrs {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, value))
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatBuildOutput(output backend_types.BuildOutput) string {
+	if output.Type == "" {
+		return ""
+	}
+
+	parts := []string{"type=" + output.Type}
+	if output.Image != "" {
+		parts = append(parts, "name="+output.Image)
+	}
+	if output.Push {
+		parts = append(parts, "push=true")
+	}
+	if output.Dest != "" {
+		parts = append(parts, "dest="+output.Dest)
+	}
+	return strings.Join(parts, ",")
+}
+
+// loginRegistry runs `docker login` against hostname with auth's
+// credentials, skipping the call entirely when no credentials are set. The
+// password is piped in via --password-stdin rather than passed as an
+// argument, so it never shows up in a `ps` listing or /proc/<pid>/cmdline.
+func loginRegistry(ctx context.Context, run Runner, hostname string, auth backend_types.Auth) error {
+	if auth.Username == "" {
+		return nil
+	}
+	return run(ctx, strings.NewReader(auth.Password), "docker", "login", hostname, "--username", auth.Username, "--password-stdin")
+}
+
+// sortedHostnames returns auths' keys in sorted order, so logins (and the
+// commands the tests assert on) happen in a deterministic sequence.
+func sortedHostnames(auths map[string]backend_types.Auth) []string {
+	hostnames := make([]string, 0, len(auths))
+	for hostname := range auths {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	return hostnames
+}
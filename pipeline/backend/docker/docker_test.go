@@ -0,0 +1,113 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+func TestBuildArgsIncludesDockerfileAndContextByDefault(t *testing.T) {
+	build := &backend_types.Build{Context: ".", Dockerfile: "Dockerfile"}
+	args := BuildArgs(build)
+
+	if args[0] != "--file" || args[1] != "Dockerfile" {
+		t.Fatalf("expected --file Dockerfile as the leading args, got %v", args)
+	}
+	if args[len(args)-1] != "." {
+		t.Fatalf("expected the context to be the final argument, got %v", args)
+	}
+}
+
+func TestBuildArgsTranslatesCacheAndOutput(t *testing.T) {
+	build := &backend_types.Build{
+		Context:    ".",
+		Dockerfile: "Dockerfile",
+		Platforms:  []string{"linux/amd64", "linux/arm64"},
+		CacheFrom:  []backend_types.BuildCache{{Type: "registry", Attrs: map[string]string{"ref": "registry.example.com/app:cache"}}},
+		CacheTo:    []backend_types.BuildCache{{Type: "registry", Attrs: map[string]string{"ref": "registry.example.com/app:cache", "mode": "max"}}},
+		Output:     backend_types.BuildOutput{Type: "image", Image: "registry.example.com/app:latest", Push: true},
+	}
+
+	args := BuildArgs(build)
+	joined := strings.Join(args, " ")
+
+	for _, want := range []string{
+		"--file Dockerfile",
+		"--platform linux/amd64",
+		"--platform linux/arm64",
+		"--cache-from type=registry,ref=registry.example.com/app:cache",
+		"--output type=image,name=registry.example.com/app:latest,push=true",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("expected build args to contain %q, got %q", want, joined)
+		}
+	}
+	if args[len(args)-1] != "." {
+		t.Fatalf("expected the context to be the final argument, got %q", args[len(args)-1])
+	}
+}
+
+func TestRunBuildLogsIntoEachAuthBeforeBuilding(t *testing.T) {
+	var commands [][]string
+	var stdins []string
+	run := func(_ context.Context, stdin io.Reader, name string, args ...string) error {
+		commands = append(commands, append([]string{name}, args...))
+		var piped string
+		if stdin != nil {
+			b, _ := io.ReadAll(stdin)
+			piped = string(b)
+		}
+		stdins = append(stdins, piped)
+		return nil
+	}
+
+	build := &backend_types.Build{
+		Context:    ".",
+		Dockerfile: "Dockerfile",
+		Output:     backend_types.BuildOutput{Type: "image", Image: "output.example.com/app:latest", Push: true},
+		Auths: map[string]backend_types.Auth{
+			"cache.example.com":  {Username: "cache-user", Password: "cache-pass"},
+			"output.example.com": {Username: "output-user", Password: "output-pass"},
+		},
+	}
+
+	if err := RunBuild(context.Background(), run, build); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(commands) != 3 {
+		t.Fatalf("expected 2 logins followed by the build, got %d commands: %v", len(commands), commands)
+	}
+	if !strings.Contains(strings.Join(commands[0], " "), "login cache.example.com") {
+		t.Fatalf("expected the cache registry to be logged into first, got %v", commands[0])
+	}
+	if strings.Contains(strings.Join(commands[0], " "), "cache-pass") {
+		t.Fatalf("expected the password to be piped via stdin, not passed as an argument: %v", commands[0])
+	}
+	if stdins[0] != "cache-pass" {
+		t.Fatalf("expected the cache registry password to be piped via stdin, got %q", stdins[0])
+	}
+	if !strings.Contains(strings.Join(commands[1], " "), "login output.example.com") {
+		t.Fatalf("expected the output registry to be logged into second, got %v", commands[1])
+	}
+	if !strings.Contains(strings.Join(commands[2], " "), "buildx build") {
+		t.Fatalf("expected the build to run last, got %v", commands[2])
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"strings"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+// PullImage pulls image, trying each of mirrors in order first and falling
+// back to pulling image from its upstream registry if every mirror fails (or
+// none are configured). It logs into an authenticated mirror before pulling
+// through it.
+func PullImage(ctx context.Context, run Runner, image string, mirrors []backend_types.Mirror) error {
+	if run == nil {
+		run = execRunner
+	}
+
+	for _, mirror := range mirrors {
+		if err := loginRegistry(ctx, run, mirror.Host, mirror.Auth); err != nil {
+			continue
+		}
+		if err := run(ctx, nil, "docker", "pull", RewriteImageForMirror(image, mirror)); err == nil {
+			return nil
+		}
+	}
+
+	return run(ctx, nil, "docker", "pull", image)
+}
+
+// RewriteImageForMirror rewrites image to pull through mirror instead of its
+// upstream registry, replacing an explicit registry hostname or, for an
+// implicit docker.io image, prefixing the mirror host.
+func RewriteImageForMirror(image string, mirror backend_types.Mirror) string {
+	if mirror.Host == "" {
+		return image
+	}
+
+	firstSlash := strings.Index(image, "/")
+	if firstSlash == -1 {
+		return mirror.Host + "/" + image
+	}
+
+	candidate := image[:firstSlash]
+	if looksLikeHost(candidate) {
+		return mirror.Host + image[firstSlash:]
+	}
+	return mirror.Host + "/" + image
+}
+
+// looksLikeHost reports whether candidate, the text before an image's first
+// "/", is a registry hostname rather than a docker.io path component like
+// "library".
+func looksLikeHost(candidate string) bool {
+	return candidate == "localhost" || strings.ContainsAny(candidate, ".:")
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+func TestRewriteImageForMirrorReplacesExplicitHost(t *testing.T) {
+	mirror := backend_types.Mirror{Host: "mirror.internal:5000"}
+
+	got := RewriteImageForMirror("registry.example.com/app:latest", mirror)
+	if got != "mirror.internal:5000/app:latest" {
+		t.Fatalf("expected the explicit host to be replaced, got %q", got)
+	}
+}
+
+func TestRewriteImageForMirrorHandlesImplicitDockerHub(t *testing.T) {
+	mirror := backend_types.Mirror{Host: "mirror.internal:5000"}
+
+	got := RewriteImageForMirror("golang:1.21", mirror)
+	if got != "mirror.internal:5000/golang:1.21" {
+		t.Fatalf("expected the mirror host to be prefixed, got %q", got)
+	}
+}
+
+func TestPullImagePrefersMirrorOverUpstream(t *testing.T) {
+	var pulled []string
+	run := func(_ context.Context, _ io.Reader, name string, args ...string) error {
+		pulled = append(pulled, strings.Join(append([]string{name}, args...), " "))
+		return nil
+	}
+
+	mirrors := []backend_types.Mirror{{Host: "mirror.internal:5000"}}
+	if err := PullImage(context.Background(), run, "golang:1.21", mirrors); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pulled) != 1 || pulled[0] != "docker pull mirror.internal:5000/golang:1.21" {
+		t.Fatalf("expected a single pull through the mirror, got %v", pulled)
+	}
+}
+
+func TestPullImageFallsBackToUpstreamWhenMirrorFails(t *testing.T) {
+	var pulled []string
+	run := func(_ context.Context, _ io.Reader, name string, args ...string) error {
+		cmd := strings.Join(append([]string{name}, args...), " ")
+		pulled = append(pulled, cmd)
+		if strings.Contains(cmd, "mirror.internal") {
+			return fmt.Errorf("mirror unreachable")
+		}
+		return nil
+	}
+
+	mirrors := []backend_types.Mirror{{Host: "mirror.internal:5000"}}
+	if err := PullImage(context.Background(), run, "golang:1.21", mirrors); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pulled) != 2 {
+		t.Fatalf("expected a failed mirror pull followed by an upstream pull, got %v", pulled)
+	}
+	if pulled[1] != "docker pull golang:1.21" {
+		t.Fatalf("expected the fallback pull to target the upstream image, got %q", pulled[1])
+	}
+}
+
+func TestPullImageLogsIntoAuthenticatedMirrorBeforePulling(t *testing.T) {
+	var commands []string
+	run := func(_ context.Context, _ io.Reader, name string, args ...string) error {
+		commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+		return nil
+	}
+
+	mirrors := []backend_types.Mirror{{
+		Host: "mirror.internal:5000",
+		Auth: backend_types.Auth{Username: "mirror-user", Password: "mirror-pass"},
+	}}
+	if err := PullImage(context.Background(), run, "golang:1.21", mirrors); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(commands) != 2 {
+		t.Fatalf("expected a login followed by a pull, got %v", commands)
+	}
+	if !strings.Contains(commands[0], "login mirror.internal:5000") {
+		t.Fatalf("expected a login to the mirror first, got %q", commands[0])
+	}
+}
+
+func TestPullImageDoesNotLoginToUnauthenticatedMirror(t *testing.T) {
+	var commands []string
+	run := func(_ context.Context, _ io.Reader, name string, args ...string) error {
+		commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+		return nil
+	}
+
+	mirrors := []backend_types.Mirror{{Host: "mirror.internal:5000"}}
+	if err := PullImage(context.Background(), run, "golang:1.21", mirrors); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(commands) != 1 {
+		t.Fatalf("expected no login call for an unauthenticated mirror, got %v", commands)
+	}
+}
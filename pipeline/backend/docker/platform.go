@@ -0,0 +1,24 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+// PlatformArgs returns the `docker run`/`docker create` flags pinning a
+// step's container to platform, or nil if the step isn't pinned to one.
+func PlatformArgs(platform string) []string {
+	if platform == "" {
+		return nil
+	}
+	return []string{"--platform", platform}
+}
@@ -0,0 +1,34 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlatformArgsReturnsNilWhenUnset(t *testing.T) {
+	if args := PlatformArgs(""); args != nil {
+		t.Fatalf("expected no args for an empty platform, got %v", args)
+	}
+}
+
+func TestPlatformArgsReturnsFlagForPlatform(t *testing.T) {
+	got := PlatformArgs("linux/arm64")
+	want := []string{"--platform", "linux/arm64"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kubernetes runs backend_types.Step definitions as Kubernetes pods.
+package kubernetes
+
+import backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+
+// PodSpec is the subset of a pod's scheduling-relevant fields the kubernetes
+// backend fills in for a step, mirroring corev1.PodSpec without depending on
+// client-go.
+type PodSpec struct {
+	NodeSelector       map[string]string
+	ServiceAccountName string
+	Tolerations        []backend_types.Toleration
+	Resources          backend_types.Resources
+}
+
+// PodSpecFor translates step's Kubernetes backend options into a PodSpec.
+// The compiler is the sole owner of NodeSelector computation (see
+// compiler.nodeSelectorForPlatform, which folds a fanned-out step's platform
+// into it at compile time) — PodSpecFor passes it through unchanged rather
+// than recomputing it from step.Platform, so the two layers can't drift out
+// of sync.
+func PodSpecFor(step *backend_types.Step) PodSpec {
+	return PodSpec{
+		NodeSelector:       step.BackendOptions.Kubernetes.NodeSelector,
+		ServiceAccountName: step.BackendOptions.Kubernetes.ServiceAccountName,
+		Tolerations:        step.BackendOptions.Kubernetes.Tolerations,
+		Resources:          step.BackendOptions.Kubernetes.Resources,
+	}
+}
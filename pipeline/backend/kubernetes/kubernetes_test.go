@@ -0,0 +1,57 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+func TestPodSpecForPassesNodeSelectorThrough(t *testing.T) {
+	step := &backend_types.Step{
+		BackendOptions: backend_types.BackendOptions{
+			Kubernetes: backend_types.KubernetesBackendOptions{
+				NodeSelector: map[string]string{"kubernetes.io/arch": "arm64"},
+			},
+		},
+	}
+
+	spec := PodSpecFor(step)
+	if spec.NodeSelector["kubernetes.io/arch"] != "arm64" {
+		t.Fatalf("expected the compiler's NodeSelector to pass through unchanged, got %+v", spec.NodeSelector)
+	}
+}
+
+func TestPodSpecForCarriesServiceAccountAndTolerations(t *testing.T) {
+	step := &backend_types.Step{
+		BackendOptions: backend_types.BackendOptions{
+			Kubernetes: backend_types.KubernetesBackendOptions{
+				ServiceAccountName: "ci-runner",
+				Tolerations: []backend_types.Toleration{
+					{Key: "dedicated", Operator: "Equal", Value: "ci", Effect: "NoSchedule"},
+				},
+			},
+		},
+	}
+
+	spec := PodSpecFor(step)
+	if spec.ServiceAccountName != "ci-runner" {
+		t.Fatalf("expected the service account name to carry through, got %q", spec.ServiceAccountName)
+	}
+	if len(spec.Tolerations) != 1 || spec.Tolerations[0].Key != "dedicated" {
+		t.Fatalf("expected the toleration to carry through, got %+v", spec.Tolerations)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestSigningPrimitivesMatchPublishedAWSSigV4TestVector validates sign's
+// underlying canonical-request hashing, string-to-sign, and signing-key
+// derivation against AWS's own published "get-vanilla" SigV4 worked example
+// (Signature Version 4 Signing Process, using the AKIDEXAMPLE test
+// credentials), independently of this package's request-building code.
+func TestSigningPrimitivesMatchPublishedAWSSigV4TestVector(t *testing.T) {
+	const (
+		amzDate   = "20150830T123600Z"
+		dateStamp = "20150830"
+		region    = "us-east-1"
+		service   = "service"
+		secretKey = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+
+		wantHashedCanonicalRequest = "bb579772317eb040ac9ed261061d46c1f17a8133879d6129b6e1c25292927e63"
+		wantSignature              = "ea21d6f05e96a897f6000a1a293f0a5bf0f92a00343409e820dce329ca6365ea"
+	)
+
+	emptyPayloadHash := sha256Hex(nil)
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		"/",
+		"",
+		"host:example.amazonaws.com",
+		"x-amz-date:" + amzDate,
+		"",
+		"host;x-amz-date",
+		emptyPayloadHash,
+	}, "\n")
+
+	hashedCanonicalRequest := sha256Hex([]byte(canonicalRequest))
+	if hashedCanonicalRequest != wantHashedCanonicalRequest {
+		t.Fatalf("hashed canonical request = %q, want %q (published AWS test vector)", hashedCanonicalRequest, wantHashedCanonicalRequest)
+	}
+
+	credentialScope := dateStamp + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		awsSigningAlgorithm,
+		amzDate,
+		credentialScope,
+		hashedCanonicalRequest,
+	}, "\n")
+
+	signingKey := awsSigningKey(secretKey, dateStamp, region, service)
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	if signature != wantSignature {
+		t.Fatalf("signature = %q, want %q (published AWS test vector)", signature, wantSignature)
+	}
+}
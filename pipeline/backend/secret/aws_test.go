@@ -0,0 +1,81 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+func TestAWSSecretsManagerProviderSignsAndResolvesPlainSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), awsSigningAlgorithm) {
+			t.Fatalf("expected a SigV4 Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			t.Fatalf("unexpected X-Amz-Target: %q", r.Header.Get("X-Amz-Target"))
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			SecretId string `json:"SecretId"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		if req.SecretId != "prod/deploy" {
+			t.Fatalf("unexpected SecretId: %q", req.SecretId)
+		}
+
+		_, _ = w.Write([]byte(`{"SecretString":"s3cr3t"}`))
+	}))
+	defer server.Close()
+
+	p := NewAWSSecretsManagerProvider("us-east-1", "AKIAEXAMPLE", "secretkeyexample")
+	p.Endpoint = server.URL
+
+	value, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "prod/deploy"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Fatalf("expected %q, got %q", "s3cr3t", value)
+	}
+}
+
+func TestAWSSecretsManagerProviderResolvesFieldFromJSONSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"SecretString":"{\"token\":\"s3cr3t\"}"}`))
+	}))
+	defer server.Close()
+
+	p := NewAWSSecretsManagerProvider("us-east-1", "AKIAEXAMPLE", "secretkeyexample")
+	p.Endpoint = server.URL
+
+	value, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "prod/deploy", Field: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Fatalf("expected %q, got %q", "s3cr3t", value)
+	}
+}
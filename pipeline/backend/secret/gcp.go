@@ -0,0 +1,141 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+// GCPSecretsManagerProvider resolves a SecretRef against GCP Secret Manager,
+// authenticating as the instance's attached service account via the GCE
+// metadata server rather than a service account key file. ref.Path is the
+// secret's ID within Project; ref.Field, if set, is a key within the
+// secret's JSON-object payload.
+type GCPSecretsManagerProvider struct {
+	Project string
+
+	// MetadataURL and APIBaseURL override the GCE metadata server and the
+	// Secret Manager API, respectively; used by tests.
+	MetadataURL string
+	APIBaseURL  string
+	Client      *http.Client
+}
+
+// NewGCPSecretsManagerProvider creates a GCPSecretsManagerProvider for project.
+func NewGCPSecretsManagerProvider(project string) *GCPSecretsManagerProvider {
+	return &GCPSecretsManagerProvider{Project: project}
+}
+
+// Resolve implements Provider.
+func (p *GCPSecretsManagerProvider) Resolve(ctx context.Context, ref backend_types.SecretRef) (string, error) {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("gcp secret manager: fetching access token: %w", err)
+	}
+
+	apiBase := p.APIBaseURL
+	if apiBase == "" {
+		apiBase = "https://secretmanager.googleapis.com"
+	}
+
+	url := fmt.Sprintf("%s/v1/projects/%s/secrets/%s/versions/latest:access", apiBase, p.Project, ref.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gcp secret manager: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gcp secret manager: unexpected status %d reading %q", resp.StatusCode, ref.Path)
+	}
+
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("gcp secret manager: decoding response for %q: %w", ref.Path, err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("gcp secret manager: decoding payload for %q: %w", ref.Path, err)
+	}
+
+	if ref.Field == "" {
+		return string(decoded), nil
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(decoded, &fields); err != nil {
+		return "", fmt.Errorf("gcp secret manager: secret %q is not a JSON object, can't read field %q", ref.Path, ref.Field)
+	}
+	value, ok := fields[ref.Field]
+	if !ok {
+		return "", fmt.Errorf("gcp secret manager: secret %q has no field %q", ref.Path, ref.Field)
+	}
+	return value, nil
+}
+
+func (p *GCPSecretsManagerProvider) accessToken(ctx context.Context) (string, error) {
+	metadataURL := p.MetadataURL
+	if metadataURL == "" {
+		metadataURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.AccessToken, nil
+}
+
+func (p *GCPSecretsManagerProvider) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return http.DefaultClient
+}
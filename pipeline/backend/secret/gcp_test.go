@@ -0,0 +1,81 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+func TestGCPSecretsManagerProviderFetchesTokenThenResolvesSecret(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			t.Fatalf("expected the metadata flavor header, got %q", r.Header.Get("Metadata-Flavor"))
+		}
+		_, _ = w.Write([]byte(`{"access_token":"test-token"}`))
+	}))
+	defer metadata.Close()
+
+	payload := base64.StdEncoding.EncodeToString([]byte("s3cr3t"))
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Fatalf("expected the fetched token to be used, got %q", r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{"payload":{"data":"` + payload + `"}}`))
+	}))
+	defer api.Close()
+
+	p := NewGCPSecretsManagerProvider("my-project")
+	p.MetadataURL = metadata.URL
+	p.APIBaseURL = api.URL
+
+	value, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "deploy-token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Fatalf("expected %q, got %q", "s3cr3t", value)
+	}
+}
+
+func TestGCPSecretsManagerProviderResolvesFieldFromJSONPayload(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"access_token":"test-token"}`))
+	}))
+	defer metadata.Close()
+
+	payload := base64.StdEncoding.EncodeToString([]byte(`{"token":"s3cr3t"}`))
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"payload":{"data":"` + payload + `"}}`))
+	}))
+	defer api.Close()
+
+	p := NewGCPSecretsManagerProvider("my-project")
+	p.MetadataURL = metadata.URL
+	p.APIBaseURL = api.URL
+
+	value, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "deploy-token", Field: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Fatalf("expected %q, got %q", "s3cr3t", value)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+// KubernetesSecretProvider resolves a SecretRef against a Kubernetes Secret
+// object, authenticating with the pod's own in-cluster service account
+// token and trusting the apiserver's certificate via the cluster's own CA
+// bundle, the same way client-go's in-cluster config does. ref.Path is the
+// Secret's name; ref.Field is the key within its (base64-encoded) data map.
+type KubernetesSecretProvider struct {
+	Namespace string
+
+	// APIServerURL, TokenPath and CACertPath override the in-cluster API
+	// server, service account token file, and CA bundle, respectively; used
+	// by tests. Setting Client explicitly bypasses CA bundle loading
+	// entirely, for a test server that isn't using TLS at all.
+	APIServerURL string
+	TokenPath    string
+	CACertPath   string
+	Client       *http.Client
+}
+
+// NewKubernetesSecretProvider creates a KubernetesSecretProvider reading
+// Secrets from namespace.
+func NewKubernetesSecretProvider(namespace string) *KubernetesSecretProvider {
+	return &KubernetesSecretProvider{Namespace: namespace}
+}
+
+// Resolve implements Provider.
+func (p *KubernetesSecretProvider) Resolve(ctx context.Context, ref backend_types.SecretRef) (string, error) {
+	token, err := p.token()
+	if err != nil {
+		return "", fmt.Errorf("kubernetes secret: reading service account token: %w", err)
+	}
+
+	apiServer := p.APIServerURL
+	if apiServer == "" {
+		apiServer = "https://kubernetes.default.svc"
+	}
+
+	url := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", apiServer, p.Namespace, ref.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client, err := p.client()
+	if err != nil {
+		return "", fmt.Errorf("kubernetes secret: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("kubernetes secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("kubernetes secret: unexpected status %d reading %q", resp.StatusCode, ref.Path)
+	}
+
+	var result struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("kubernetes secret: decoding response for %q: %w", ref.Path, err)
+	}
+
+	encoded, ok := result.Data[ref.Field]
+	if !ok {
+		return "", fmt.Errorf("kubernetes secret: %q has no key %q", ref.Path, ref.Field)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("kubernetes secret: decoding key %q of %q: %w", ref.Field, ref.Path, err)
+	}
+	return string(decoded), nil
+}
+
+func (p *KubernetesSecretProvider) token() (string, error) {
+	tokenPath := p.TokenPath
+	if tokenPath == "" {
+		tokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	}
+	data, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// client returns the http.Client used to talk to the apiserver, loading the
+// in-cluster CA bundle into a dedicated tls.Config so the apiserver's
+// cluster-CA-signed certificate verifies, the way client-go does. An
+// explicit Client override bypasses CA loading entirely.
+func (p *KubernetesSecretProvider) client() (*http.Client, error) {
+	if p.Client != nil {
+		return p.Client, nil
+	}
+
+	caCertPath := p.CACertPath
+	if caCertPath == "" {
+		caCertPath = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	}
+	caCert, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading in-cluster CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in %q", caCertPath)
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}, nil
+}
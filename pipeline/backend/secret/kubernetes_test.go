@@ -0,0 +1,126 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+func writeTestToken(t *testing.T, token string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		t.Fatalf("failed to write test token: %v", err)
+	}
+	return path
+}
+
+func TestKubernetesSecretProviderResolvesBase64EncodedKey(t *testing.T) {
+	tokenPath := writeTestToken(t, "test-token")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("s3cr3t"))
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/ci/secrets/deploy" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Fatalf("expected the service account token to be used, got %q", r.Header.Get("Authorization"))
+		}
+		_, _ = w.Write([]byte(`{"data":{"token":"` + encoded + `"}}`))
+	}))
+	defer api.Close()
+
+	p := NewKubernetesSecretProvider("ci")
+	p.APIServerURL = api.URL
+	p.TokenPath = tokenPath
+	p.Client = api.Client()
+
+	value, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "deploy", Field: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Fatalf("expected %q, got %q", "s3cr3t", value)
+	}
+}
+
+func TestKubernetesSecretProviderFailsForMissingKey(t *testing.T) {
+	tokenPath := writeTestToken(t, "test-token")
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"other":"dmFsdWU="}}`))
+	}))
+	defer api.Close()
+
+	p := NewKubernetesSecretProvider("ci")
+	p.APIServerURL = api.URL
+	p.TokenPath = tokenPath
+	p.Client = api.Client()
+
+	if _, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "deploy", Field: "token"}); err == nil {
+		t.Fatal("expected an error for a key absent from the secret's data")
+	}
+}
+
+func TestKubernetesSecretProviderLoadsInClusterCABundle(t *testing.T) {
+	tokenPath := writeTestToken(t, "test-token")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("s3cr3t"))
+	api := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"token":"` + encoded + `"}}`))
+	}))
+	defer api.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: api.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0o600); err != nil {
+		t.Fatalf("failed to write test CA bundle: %v", err)
+	}
+
+	p := NewKubernetesSecretProvider("ci")
+	p.APIServerURL = api.URL
+	p.TokenPath = tokenPath
+	p.CACertPath = caPath
+
+	value, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "deploy", Field: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error resolving against a server trusted via the loaded CA bundle: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Fatalf("expected %q, got %q", "s3cr3t", value)
+	}
+}
+
+func TestKubernetesSecretProviderFailsWhenCABundleMissing(t *testing.T) {
+	tokenPath := writeTestToken(t, "test-token")
+
+	p := NewKubernetesSecretProvider("ci")
+	p.APIServerURL = "https://kubernetes.default.svc"
+	p.TokenPath = tokenPath
+	p.CACertPath = filepath.Join(t.TempDir(), "missing-ca.crt")
+
+	if _, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "deploy", Field: "token"}); err == nil {
+		t.Fatal("expected an error when the in-cluster CA bundle can't be read")
+	}
+}
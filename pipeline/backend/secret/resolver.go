@@ -0,0 +1,54 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secret resolves a backend_types.SecretRef against the external
+// secret store named by its Provider, at step-execution time.
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+// Provider resolves a SecretRef's value from one external secret store.
+type Provider interface {
+	Resolve(ctx context.Context, ref backend_types.SecretRef) (string, error)
+}
+
+// Resolver dispatches a SecretRef to the Provider registered for its Kind.
+type Resolver struct {
+	providers map[backend_types.SecretProviderKind]Provider
+}
+
+// NewResolver creates an empty Resolver; register providers with Register.
+func NewResolver() *Resolver {
+	return &Resolver{providers: map[backend_types.SecretProviderKind]Provider{}}
+}
+
+// Register binds provider to every SecretRef whose Provider is kind.
+func (r *Resolver) Register(kind backend_types.SecretProviderKind, provider Provider) {
+	r.providers[kind] = provider
+}
+
+// Resolve looks up the Provider registered for ref.Provider and resolves ref
+// against it.
+func (r *Resolver) Resolve(ctx context.Context, ref backend_types.SecretRef) (string, error) {
+	provider, ok := r.providers[ref.Provider]
+	if !ok {
+		return "", fmt.Errorf("no secret provider registered for %q", ref.Provider)
+	}
+	return provider.Resolve(ctx, ref)
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+type stubProvider struct {
+	value string
+	err   error
+}
+
+func (p stubProvider) Resolve(_ context.Context, _ backend_types.SecretRef) (string, error) {
+	return p.value, p.err
+}
+
+func TestResolverDispatchesToRegisteredProvider(t *testing.T) {
+	r := NewResolver()
+	r.Register(backend_types.SecretProviderVault, stubProvider{value: "s3cr3t"})
+
+	value, err := r.Resolve(context.Background(), backend_types.SecretRef{Provider: backend_types.SecretProviderVault})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Fatalf("expected the registered provider's value, got %q", value)
+	}
+}
+
+func TestResolverFailsForUnregisteredProvider(t *testing.T) {
+	r := NewResolver()
+
+	if _, err := r.Resolve(context.Background(), backend_types.SecretRef{Provider: backend_types.SecretProviderAWS}); err == nil {
+		t.Fatal("expected an error when no provider is registered for the ref's kind")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+// VaultProvider resolves a SecretRef against a HashiCorp Vault KV v2 mount,
+// reading ref.Path (e.g. "secret/data/deploy") and ref.Field within it.
+type VaultProvider struct {
+	Address string
+	Token   string
+	Client  *http.Client
+}
+
+// NewVaultProvider creates a VaultProvider authenticating with token against
+// the Vault server at address.
+func NewVaultProvider(address, token string) *VaultProvider {
+	return &VaultProvider{Address: strings.TrimRight(address, "/"), Token: token}
+}
+
+// Resolve implements Provider.
+func (p *VaultProvider) Resolve(ctx context.Context, ref backend_types.SecretRef) (string, error) {
+	url := fmt.Sprintf("%s/v1/%s", p.Address, strings.TrimLeft(ref.Path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: unexpected status %d reading %q", resp.StatusCode, ref.Path)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("vault: decoding response for %q: %w", ref.Path, err)
+	}
+
+	value, ok := body.Data.Data[ref.Field]
+	if !ok {
+		return "", fmt.Errorf("vault: secret %q has no field %q", ref.Path, ref.Field)
+	}
+	return value, nil
+}
+
+func (p *VaultProvider) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return http.DefaultClient
+}
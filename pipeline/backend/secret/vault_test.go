@@ -0,0 +1,59 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+)
+
+func TestVaultProviderResolvesFieldFromKVv2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/deploy" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			t.Fatalf("expected the vault token header to be set, got %q", r.Header.Get("X-Vault-Token"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"data":{"token":"s3cr3t"}}}`))
+	}))
+	defer server.Close()
+
+	p := NewVaultProvider(server.URL, "test-token")
+	value, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "secret/data/deploy", Field: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "s3cr3t" {
+		t.Fatalf("expected %q, got %q", "s3cr3t", value)
+	}
+}
+
+func TestVaultProviderFailsForMissingField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"data":{"other":"value"}}}`))
+	}))
+	defer server.Close()
+
+	p := NewVaultProvider(server.URL, "test-token")
+	if _, err := p.Resolve(context.Background(), backend_types.SecretRef{Path: "secret/data/deploy", Field: "token"}); err == nil {
+		t.Fatal("expected an error for a field absent from the secret")
+	}
+}
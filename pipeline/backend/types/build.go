@@ -0,0 +1,53 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// Build is the backend-agnostic description of a step compiled from a
+// `build:` block. Docker and kubernetes backends execute it via a buildkitd
+// sidecar or the `buildx` binary instead of running Image directly.
+type Build struct {
+	Context    string
+	Dockerfile string
+	Platforms  []string
+	Target     string
+	BuildArgs  map[string]string
+	Secrets    []string
+	SSH        []string
+	CacheFrom  []BuildCache
+	CacheTo    []BuildCache
+	Output     BuildOutput
+
+	// Auths holds registry credentials keyed by hostname, covering the
+	// output image's registry and every cache_from/cache_to ref, so the
+	// backend can log into each one before the push/cache-export legs of
+	// the build need to authenticate.
+	Auths map[string]Auth
+}
+
+// BuildCache is one `cache_from`/`cache_to` entry passed to buildx as
+// `--cache-from type=<Type>,<k>=<v>,...`.
+type BuildCache struct {
+	Type  string
+	Attrs map[string]string
+}
+
+// BuildOutput is a build's `output:` block, passed to buildx as
+// `--output type=<Type>,...`.
+type BuildOutput struct {
+	Type  string
+	Image string
+	Push  bool
+	Dest  string
+}
@@ -0,0 +1,26 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// Config is the compiled, backend-agnostic pipeline a backend executes:
+// an ordered list of stages, each running its steps.
+type Config struct {
+	Stages []*Stage
+}
+
+// Stage is a group of Steps a backend may run concurrently.
+type Stage struct {
+	Steps []*Step
+}
@@ -0,0 +1,27 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// Mirror is a pull-through registry mirror a step's image should be pulled
+// through before falling back to its upstream registry.
+type Mirror struct {
+	Host     string
+	Insecure bool
+
+	// Auth holds credentials for Host, kept separate from the step's own
+	// AuthConfig so mirror credentials never leak to the upstream registry,
+	// nor upstream credentials to the mirror.
+	Auth Auth
+}
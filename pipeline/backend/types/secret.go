@@ -0,0 +1,41 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// SecretProviderKind identifies which external secret store a SecretRef
+// resolves against at step-execution time.
+type SecretProviderKind string
+
+const (
+	// SecretProviderVault resolves against a HashiCorp Vault KV v2 mount.
+	SecretProviderVault SecretProviderKind = "vault"
+	// SecretProviderAWS resolves against AWS Secrets Manager.
+	SecretProviderAWS SecretProviderKind = "aws-secrets-manager"
+	// SecretProviderGCP resolves against GCP Secret Manager.
+	SecretProviderGCP SecretProviderKind = "gcp-secret-manager"
+	// SecretProviderKubernetes resolves against a Kubernetes Secret object.
+	SecretProviderKubernetes SecretProviderKind = "kubernetes"
+)
+
+// SecretRef is a step's reference to a secret to be resolved by Provider at
+// step-execution time, rather than materialized into Environment at compile
+// time the way a built-in-store secret is.
+type SecretRef struct {
+	Provider  SecretProviderKind
+	Path      string
+	Field     string
+	TargetEnv string
+	Mask      bool
+}
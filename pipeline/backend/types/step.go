@@ -0,0 +1,162 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package types holds the backend-agnostic description of a pipeline that the
+// compiler produces and that the docker/kubernetes/... backends execute.
+package types
+
+import "github.com/woodpecker-ci/woodpecker/pipeline/frontend/metadata"
+
+// StepType distinguishes how a Step should be executed.
+type StepType int
+
+const (
+	// StepTypeCommands runs container.Commands inside the step's image.
+	StepTypeCommands StepType = iota
+	// StepTypeService runs a detached, long-lived container alongside the
+	// pipeline's steps.
+	StepTypeService
+	// StepTypeBuild runs a BuildKit build (see Step.Build) instead of Image.
+	StepTypeBuild
+)
+
+// Step is the backend-agnostic description of a single unit of work a backend
+// executes: a plain command step, a detached service, or (see Build) a
+// BuildKit build.
+type Step struct {
+	Name           string
+	UUID           string
+	Type           StepType
+	Alias          string
+	Image          string
+	Pull           bool
+	Detached       bool
+	Privileged     bool
+	WorkingDir     string
+	Environment    map[string]string
+	Commands       []string
+	ExtraHosts     []string
+	Volumes        []string
+	Tmpfs          []string
+	Devices        []string
+	Networks       []Conn
+	DNS            []string
+	DNSSearch      []string
+	MemSwapLimit   int64
+	MemLimit       int64
+	ShmSize        int64
+	Sysctls        map[string]string
+	CPUQuota       int64
+	CPUShares      int64
+	CPUSet         string
+	AuthConfig     Auth
+	OnSuccess      bool
+	OnFailure      bool
+	Failure        string
+	NetworkMode    string
+	IpcMode        string
+	BackendOptions BackendOptions
+
+	// ImageTrust is the resolved trust policy gating this step's image, or nil
+	// if no policy matches (or the step opted out via `verify: {disabled: true}`).
+	ImageTrust *ImageTrust
+
+	// Build is set when Type is StepTypeBuild, describing the BuildKit
+	// invocation the backend should run instead of Image.
+	Build *Build
+
+	// RegistryMirrors lists the pull-through mirrors, if any, configured for
+	// Image's registry. A backend should try each in order before falling
+	// back to pulling Image from its upstream registry directly.
+	RegistryMirrors []Mirror
+
+	// Platform is the "os/arch[/variant]" this step was fanned out for, or
+	// empty if the step isn't pinned to a single platform.
+	Platform string
+
+	// FanOutGroup, when non-empty, is the ID shared by every sibling Step a
+	// multi-platform step was expanded into. Steps sharing a FanOutGroup are
+	// one logical step for When/OnSuccess/OnFailure purposes: see FanOutFailed.
+	FanOutGroup string
+
+	// SecretRefs lists the secrets a backend must resolve through an
+	// external SecretProvider before starting the step, rather than ones
+	// already inlined into Environment at compile time.
+	SecretRefs []SecretRef
+
+	// MaskedValues holds secret values already known at compile time (inline
+	// built-in-store secrets) that the log pipeline must mask, preserved
+	// alongside SecretRefs for backward compatibility with existing workflows.
+	MaskedValues []string
+}
+
+// FailureOrDefault returns the step's Failure mode, defaulting to
+// metadata.FailureFail when unset, the way the compiler leaves it.
+func (s *Step) FailureOrDefault() metadata.Failure {
+	if s.Failure == "" {
+		return metadata.FailureFail
+	}
+	return metadata.Failure(s.Failure)
+}
+
+// Conn is a network a step's container attaches to, optionally under one or
+// more aliases.
+type Conn struct {
+	Name    string
+	Aliases []string
+}
+
+// Auth holds registry credentials for pulling a step's image.
+type Auth struct {
+	Username string
+	Password string
+	Email    string
+}
+
+// BackendOptions holds advanced, backend-specific settings carried over from
+// a step's `backend_options:` block.
+type BackendOptions struct {
+	Kubernetes KubernetesBackendOptions
+}
+
+// KubernetesBackendOptions are the advanced settings the kubernetes backend
+// consumes when scheduling a step's pod.
+type KubernetesBackendOptions struct {
+	Resources          Resources
+	ServiceAccountName string
+	NodeSelector       map[string]string
+	Tolerations        []Toleration
+}
+
+// Resources mirrors the Kubernetes resource requests/limits syntax.
+type Resources struct {
+	Limits   map[string]string
+	Requests map[string]string
+}
+
+// TolerationOperator mirrors corev1.TolerationOperator without importing
+// client-go.
+type TolerationOperator string
+
+// TaintEffect mirrors corev1.TaintEffect without importing client-go.
+type TaintEffect string
+
+// Toleration mirrors a Kubernetes pod toleration.
+type Toleration struct {
+	Key               string
+	Operator          TolerationOperator
+	Value             string
+	Effect            TaintEffect
+	TolerationSeconds *int64
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// TrustPolicyKind identifies the verifier technology a trust policy checks an
+// image against. It is orthogonal to whether a missing verifier should fail
+// compilation (see TrustPolicyMode).
+type TrustPolicyKind string
+
+const (
+	// TrustPolicyDisabled performs no verification.
+	TrustPolicyDisabled TrustPolicyKind = "disabled"
+	// TrustPolicyNotaryV1 verifies against a Notary v1 / TUF trust collection.
+	TrustPolicyNotaryV1 TrustPolicyKind = "notary-v1"
+	// TrustPolicyCosignKeyless verifies a cosign keyless (Fulcio/Rekor) signature.
+	TrustPolicyCosignKeyless TrustPolicyKind = "cosign-keyless"
+	// TrustPolicyCosignKey verifies a cosign signature against a static public key.
+	TrustPolicyCosignKey TrustPolicyKind = "cosign-key"
+)
+
+// TrustPolicyMode controls what happens when a policy's Kind has no verifier
+// configuration available to act on (e.g. a cosign-key policy with no public
+// key). It is independent of Kind, which only names the verification method.
+type TrustPolicyMode string
+
+const (
+	// TrustPolicyModeEnforce fails compilation when the policy can't be satisfied.
+	TrustPolicyModeEnforce TrustPolicyMode = "enforce"
+	// TrustPolicyModeWarn logs and continues when the policy can't be satisfied.
+	TrustPolicyModeWarn TrustPolicyMode = "warn"
+)
+
+// ImageTrust is the resolved trust requirement a backend must satisfy before
+// pulling/running a step's image.
+type ImageTrust struct {
+	Policy TrustPolicyKind
+
+	// PublicKey is the cosign public key material for TrustPolicyCosignKey.
+	PublicKey string
+	// Identities and OIDCIssuers constrain TrustPolicyCosignKeyless to
+	// signatures from a matching Fulcio-issued identity/issuer pair.
+	Identities  []string
+	OIDCIssuers []string
+	// TUFRoot is the TUF root of trust for TrustPolicyNotaryV1.
+	TUFRoot string
+	// RekorURL is the transparency log used to verify cosign signatures.
+	RekorURL string
+}
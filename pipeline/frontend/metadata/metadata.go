@@ -0,0 +1,28 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metadata carries the information about a pipeline run that is made
+// available to steps at runtime (CI_* environment variables) and to the
+// compiler while translating a workflow into backend steps.
+package metadata
+
+// Failure controls how a failing step affects the rest of the pipeline.
+type Failure string
+
+const (
+	// FailureFail marks the pipeline failed when the step fails.
+	FailureFail Failure = "fail"
+	// FailureIgnore lets the pipeline continue when the step fails.
+	FailureIgnore Failure = "ignore"
+)
@@ -0,0 +1,56 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+	yaml_types "github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/types"
+)
+
+// Compile translates a workflow's parsed services and steps into a
+// backend-agnostic Config, ready for a backend to execute.
+func (c *Compiler) Compile(services, steps []*yaml_types.Container) (*backend_types.Config, error) {
+	config := new(backend_types.Config)
+
+	var serviceSteps []*backend_types.Step
+	for _, service := range services {
+		compiled, err := c.createProcess(service.Name, service, backend_types.StepTypeService)
+		if err != nil {
+			return nil, err
+		}
+		serviceSteps = append(serviceSteps, compiled...)
+	}
+	if len(serviceSteps) != 0 {
+		config.Stages = append(config.Stages, &backend_types.Stage{Steps: serviceSteps})
+	}
+
+	for _, step := range steps {
+		stepType := backend_types.StepTypeCommands
+		if step.Build != nil {
+			stepType = backend_types.StepTypeBuild
+		}
+
+		// A multi-platform step compiles to several sibling Steps sharing a
+		// FanOutGroup; they run together in one Stage so the backend
+		// schedules them concurrently.
+		compiled, err := c.createProcess(step.Name, step, stepType)
+		if err != nil {
+			return nil, err
+		}
+		config.Stages = append(config.Stages, &backend_types.Stage{Steps: compiled})
+	}
+
+	return config, nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compiler translates a parsed YAML workflow into the backend-agnostic
+// pipeline description backends execute.
+package compiler
+
+import (
+	"strings"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+	yaml_types "github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/types"
+)
+
+// Compiler holds the configuration createProcess needs to turn a workflow's
+// steps into backend_types.Steps: naming/workspace conventions, resource
+// defaults, and the registry/secret/trust-policy configuration an operator
+// has set up server-side.
+type Compiler struct {
+	prefix string
+	base   string
+	path   string
+	local  bool
+
+	volumes  []string
+	networks []string
+	env      map[string]string
+
+	escalated []string
+	reslimit  ResourceLimit
+
+	registries []Registry
+	mirrors    []Mirror
+
+	// platforms is the workflow's top-level `platforms:` default, applied to
+	// a step whose own Container.Platforms is unset.
+	platforms []string
+
+	secrets         map[string]Secret
+	secretProviders map[string]SecretProviderBinding
+
+	// trustPolicies are matched against a step's image by hostname, the same
+	// way registries are, to decide what (if anything) must verify it.
+	trustPolicies []TrustPolicy
+}
+
+// Option configures a Compiler built with New.
+type Option func(*Compiler)
+
+// New creates a Compiler, applying every given Option.
+func New(opts ...Option) *Compiler {
+	c := &Compiler{
+		env:             map[string]string{},
+		secrets:         map[string]Secret{},
+		secretProviders: map[string]SecretProviderBinding{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithPrefix sets the prefix used to namespace the workspace volume and
+// default network.
+func WithPrefix(prefix string) Option {
+	return func(c *Compiler) { c.prefix = prefix }
+}
+
+// WithWorkspace sets the workspace base and the step-relative path within it.
+func WithWorkspace(base, path string) Option {
+	return func(c *Compiler) { c.base = base; c.path = path }
+}
+
+// WithLocal sets whether steps run against a local workspace instead of the
+// shared workspace volume.
+func WithLocal(local bool) Option {
+	return func(c *Compiler) { c.local = local }
+}
+
+// WithVolumes adds extra volumes mounted into every step.
+func WithVolumes(volumes []string) Option {
+	return func(c *Compiler) { c.volumes = volumes }
+}
+
+// WithNetworks adds extra networks every step attaches to.
+func WithNetworks(networks []string) Option {
+	return func(c *Compiler) { c.networks = networks }
+}
+
+// WithEnviron sets the default environment variables merged into every step.
+func WithEnviron(env map[string]string) Option {
+	return func(c *Compiler) { c.env = env }
+}
+
+// WithEscalated lists image patterns (see utils.MatchImage) that run
+// privileged when used as a plugin.
+func WithEscalated(images ...string) Option {
+	return func(c *Compiler) { c.escalated = images }
+}
+
+// WithResourceLimit sets the server-enforced resource ceilings.
+func WithResourceLimit(limit ResourceLimit) Option {
+	return func(c *Compiler) { c.reslimit = limit }
+}
+
+// WithRegistry registers credentials for a registry hostname.
+func WithRegistry(registry Registry) Option {
+	return func(c *Compiler) { c.registries = append(c.registries, registry) }
+}
+
+// WithMirror registers a pull-through mirror for a registry hostname.
+func WithMirror(mirror Mirror) Option {
+	return func(c *Compiler) { c.mirrors = append(c.mirrors, mirror) }
+}
+
+// WithPlatforms sets the workflow's top-level `platforms:` default, applied
+// to a step whose own `platforms:` is unset.
+func WithPlatforms(platforms []string) Option {
+	return func(c *Compiler) { c.platforms = platforms }
+}
+
+// WithTrustPolicy registers an image-verification policy for a registry hostname.
+func WithTrustPolicy(policy TrustPolicy) Option {
+	return func(c *Compiler) { c.trustPolicies = append(c.trustPolicies, policy) }
+}
+
+// WithSecret registers a built-in-store secret, resolved inline at compile time.
+func WithSecret(secret Secret) Option {
+	return func(c *Compiler) {
+		c.secrets[strings.ToLower(secret.Name)] = secret
+	}
+}
+
+// WithSecretProvider registers a secret resolved through an external
+// SecretProvider at step-execution time, rather than inlined at compile
+// time. A name also registered with WithSecret is shadowed: the provider
+// binding wins.
+func WithSecretProvider(binding SecretProviderBinding) Option {
+	return func(c *Compiler) {
+		c.secretProviders[strings.ToLower(binding.Name)] = binding
+	}
+}
+
+// ResourceLimit holds the server-enforced resource ceilings applied to every
+// step, overriding whatever a step requests for itself.
+type ResourceLimit struct {
+	MemSwapLimit int64
+	MemLimit     int64
+	ShmSize      int64
+	CPUQuota     int64
+	CPUShares    int64
+	CPUSet       string
+}
+
+// Registry is a configured set of credentials for a registry hostname.
+type Registry struct {
+	Hostname string
+	Username string
+	Password string
+	Email    string
+}
+
+// Mirror is a configured pull-through mirror for a registry hostname. Source
+// may be "*" to match docker.io, the same convenience shorthand operators
+// reach for to mirror Docker Hub without typing out its hostname.
+type Mirror struct {
+	Source     string
+	MirrorHost string
+	Insecure   bool
+
+	Username string
+	Password string
+	Email    string
+}
+
+// Secret is a pipeline secret resolved from the built-in secret store,
+// available to a container when it passes its `available:` gating.
+type Secret struct {
+	Name  string
+	Value string
+
+	Gate secretGate
+}
+
+// Available reports whether the secret may be used by container, honoring the
+// `available:` gating it was registered with.
+func (s Secret) Available(container *yaml_types.Container) bool {
+	return s.Gate.available(container)
+}
+
+// secretGate is the `available:` condition attached to a secret.
+type secretGate struct {
+	// PluginsOnly restricts the secret to steps that run as a plugin (no
+	// explicit commands), the same restriction `plugin_only` has always applied.
+	PluginsOnly bool
+}
+
+func (g secretGate) available(container *yaml_types.Container) bool {
+	return !g.PluginsOnly || container.IsPlugin()
+}
+
+// TrustPolicy is the per-registry image-verification configuration an
+// operator configures server-side. A step's image is matched against it by
+// hostname, using the same Registry-style lookup as credentials.
+type TrustPolicy struct {
+	Hostname string
+	Kind     backend_types.TrustPolicyKind
+	Mode     backend_types.TrustPolicyMode
+
+	PublicKey   string
+	Identities  []string
+	OIDCIssuers []string
+	TUFRoot     string
+	RekorURL    string
+}
+
+// HasVerifier reports whether enough configuration is present for Kind to
+// actually verify an image: a public key for cosign-key, an OIDC
+// issuer+Rekor URL for cosign-keyless, and a TUF root for notary-v1.
+func (p TrustPolicy) HasVerifier() bool {
+	switch p.Kind {
+	case backend_types.TrustPolicyDisabled:
+		return true
+	case backend_types.TrustPolicyCosignKey:
+		return p.PublicKey != ""
+	case backend_types.TrustPolicyCosignKeyless:
+		return len(p.OIDCIssuers) > 0 && p.RekorURL != ""
+	case backend_types.TrustPolicyNotaryV1:
+		return p.TUFRoot != ""
+	default:
+		return false
+	}
+}
+
+type secretMap map[string]Secret
+
+func (m secretMap) toStringMap() map[string]string {
+	out := make(map[string]string, len(m))
+	for name, secret := range m {
+		out[name] = secret.Value
+	}
+	return out
+}
@@ -30,7 +30,53 @@ import (
 	"github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/utils"
 )
 
-func (c *Compiler) createProcess(name string, container *yaml_types.Container, stepType backend_types.StepType) *backend_types.Step {
+// createProcess compiles container into one or more backend_types.Steps:
+// one, if it declares a single platform (or none), or one per platform, each
+// sharing a FanOutGroup, if it declares more than one. A StepTypeService
+// container never fans out — a service is a single long-lived instance.
+func (c *Compiler) createProcess(name string, container *yaml_types.Container, stepType backend_types.StepType) ([]*backend_types.Step, error) {
+	platforms := container.Platforms
+	if len(platforms) == 0 {
+		platforms = c.platforms
+	}
+	if stepType == backend_types.StepTypeService {
+		platforms = nil
+	}
+
+	if len(platforms) <= 1 {
+		var platform string
+		if len(platforms) == 1 {
+			platform = platforms[0]
+		}
+		step, err := c.createStep(name, container.Name, container, stepType, platform)
+		if err != nil {
+			return nil, err
+		}
+		return []*backend_types.Step{step}, nil
+	}
+
+	fanOutGroup := uuid.NewString()
+	steps := make([]*backend_types.Step, 0, len(platforms))
+	for _, platform := range platforms {
+		step, err := c.createStep(name, fanOutAlias(container.Name, platform), container, stepType, platform)
+		if err != nil {
+			return nil, err
+		}
+		step.FanOutGroup = fanOutGroup
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// fanOutAlias derives a sibling step's container alias from its platform, so
+// fanned-out containers (and their network aliases) don't collide.
+func fanOutAlias(alias, platform string) string {
+	return alias + "-" + strings.ReplaceAll(platform, "/", "-")
+}
+
+// createStep compiles a single backend_types.Step for one platform (or no
+// particular platform, if platform is empty) of container.
+func (c *Compiler) createStep(name, alias string, container *yaml_types.Container, stepType backend_types.StepType, platform string) (*backend_types.Step, error) {
 	var (
 		uuid = uuid.New()
 
@@ -47,7 +93,7 @@ func (c *Compiler) createProcess(name string, container *yaml_types.Container, s
 	networks := []backend_types.Conn{
 		{
 			Name:    fmt.Sprintf("%s_default", c.prefix),
-			Aliases: []string{container.Name},
+			Aliases: []string{alias},
 		},
 	}
 	for _, network := range c.networks {
@@ -89,8 +135,21 @@ func (c *Compiler) createProcess(name string, container *yaml_types.Container, s
 			}
 		}
 
-		if err := settings.ParamsToEnv(container.Settings, environment, pluginSecrets.toStringMap()); err != nil {
-			log.Error().Err(err).Msg("paramsToEnv")
+		// A name bound to an external provider can't be substituted into a
+		// plugin setting at compile time: the provider is only ever
+		// consulted at step-execution time (see c.secretProviders above).
+		// Referencing one here is a configuration error we want to fail
+		// compilation for, rather than silently leaving the placeholder in
+		// the rendered setting.
+		unresolvedSecrets := map[string]bool{}
+		for name, binding := range c.secretProviders {
+			if binding.Available(container) {
+				unresolvedSecrets[name] = true
+			}
+		}
+
+		if err := settings.ParamsToEnv(container.Settings, environment, pluginSecrets.toStringMap(), unresolvedSecrets); err != nil {
+			return nil, fmt.Errorf("step %q: %w", container.Name, err)
 		}
 	}
 
@@ -108,13 +167,59 @@ func (c *Compiler) createProcess(name string, container *yaml_types.Container, s
 		}
 	}
 
+	registryMirrors := c.registryMirrorsFor(container)
+
+	var secretRefs []backend_types.SecretRef
+	var maskedValues []string
 	for _, requested := range container.Secrets.Secrets {
-		secret, ok := c.secrets[strings.ToLower(requested.Source)]
-		if ok && secret.Available(container) {
-			environment[strings.ToUpper(requested.Target)] = secret.Value
+		source := strings.ToLower(requested.Source)
+		target := strings.ToUpper(requested.Target)
+
+		// A name bound to an external provider is resolved by the backend at
+		// step-execution time: the compiler only emits a reference, gated
+		// the same way an inline secret's `available:` is, before the
+		// provider is ever consulted.
+		if binding, ok := c.secretProviders[source]; ok {
+			if !binding.Available(container) {
+				continue
+			}
+			secretRefs = append(secretRefs, backend_types.SecretRef{
+				Provider:  binding.Provider,
+				Path:      binding.Path,
+				Field:     binding.Field,
+				TargetEnv: target,
+				Mask:      true,
+			})
+			continue
+		}
+
+		// Fall back to an inline, built-in-store secret resolved now, for
+		// backward compatibility with workflows that never registered a
+		// provider for this name.
+		if secret, ok := c.secrets[source]; ok && secret.Available(container) {
+			environment[target] = secret.Value
+			maskedValues = append(maskedValues, secret.Value)
+		}
+	}
+
+	imageTrust, err := c.imageTrustFor(container)
+	if err != nil {
+		return nil, err
+	}
+
+	var build *backend_types.Build
+	if stepType == backend_types.StepTypeBuild {
+		build, err = c.buildFor(container)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	nodeSelectorPlatform := platform
+	if nodeSelectorPlatform == "" && build != nil && len(build.Platforms) == 1 {
+		nodeSelectorPlatform = build.Platforms[0]
+	}
+
 	var tolerations []backend_types.Toleration
 	for _, t := range container.BackendOptions.Kubernetes.Tolerations {
 		tolerations = append(tolerations, backend_types.Toleration{
@@ -134,7 +239,7 @@ func (c *Compiler) createProcess(name string, container *yaml_types.Container, s
 				Requests: container.BackendOptions.Kubernetes.Resources.Requests,
 			},
 			ServiceAccountName: container.BackendOptions.Kubernetes.ServiceAccountName,
-			NodeSelector:       container.BackendOptions.Kubernetes.NodeSelector,
+			NodeSelector:       nodeSelectorForPlatform(container.BackendOptions.Kubernetes.NodeSelector, nodeSelectorPlatform),
 			Tolerations:        tolerations,
 		},
 	}
@@ -171,43 +276,206 @@ func (c *Compiler) createProcess(name string, container *yaml_types.Container, s
 
 	failure := container.Failure
 	if container.Failure == "" {
-		failure = metadata.FailureFail
+		failure = string(metadata.FailureFail)
 	}
 
 	return &backend_types.Step{
-		Name:           name,
-		UUID:           uuid.String(),
-		Type:           stepType,
-		Alias:          container.Name,
-		Image:          container.Image,
-		Pull:           container.Pull,
-		Detached:       detached,
-		Privileged:     privileged,
-		WorkingDir:     workingdir,
-		Environment:    environment,
-		Commands:       container.Commands,
-		ExtraHosts:     container.ExtraHosts,
-		Volumes:        volumes,
-		Tmpfs:          container.Tmpfs,
-		Devices:        container.Devices,
-		Networks:       networks,
-		DNS:            container.DNS,
-		DNSSearch:      container.DNSSearch,
-		MemSwapLimit:   memSwapLimit,
-		MemLimit:       memLimit,
-		ShmSize:        shmSize,
-		Sysctls:        container.Sysctls,
-		CPUQuota:       cpuQuota,
-		CPUShares:      cpuShares,
-		CPUSet:         cpuSet,
-		AuthConfig:     authConfig,
-		OnSuccess:      onSuccess,
-		OnFailure:      onFailure,
-		Failure:        failure,
-		NetworkMode:    networkMode,
-		IpcMode:        ipcMode,
-		BackendOptions: backendOptions,
+		Name:            name,
+		UUID:            uuid.String(),
+		Type:            stepType,
+		Alias:           alias,
+		Image:           container.Image,
+		Pull:            container.Pull,
+		Detached:        detached,
+		Privileged:      privileged,
+		WorkingDir:      workingdir,
+		Environment:     environment,
+		Commands:        container.Commands,
+		ExtraHosts:      container.ExtraHosts,
+		Volumes:         volumes,
+		Tmpfs:           container.Tmpfs,
+		Devices:         container.Devices,
+		Networks:        networks,
+		DNS:             container.DNS,
+		DNSSearch:       container.DNSSearch,
+		MemSwapLimit:    memSwapLimit,
+		MemLimit:        memLimit,
+		ShmSize:         shmSize,
+		Sysctls:         container.Sysctls,
+		CPUQuota:        cpuQuota,
+		CPUShares:       cpuShares,
+		CPUSet:          cpuSet,
+		AuthConfig:      authConfig,
+		OnSuccess:       onSuccess,
+		OnFailure:       onFailure,
+		Failure:         failure,
+		NetworkMode:     networkMode,
+		IpcMode:         ipcMode,
+		BackendOptions:  backendOptions,
+		ImageTrust:      imageTrust,
+		Build:           build,
+		RegistryMirrors: registryMirrors,
+		Platform:        platform,
+		SecretRefs:      secretRefs,
+		MaskedValues:    maskedValues,
+	}, nil
+}
+
+// imageTrustFor resolves the trust policy that applies to the step's image, honoring a
+// per-step `verify:` opt-out, and fails compilation when a registry is configured to
+// enforce verification but no verifier is available to satisfy it.
+func (c *Compiler) imageTrustFor(container *yaml_types.Container) (*backend_types.ImageTrust, error) {
+	if container.Verify != nil && container.Verify.Disabled {
+		return nil, nil
+	}
+
+	for _, policy := range c.trustPolicies {
+		if !utils.MatchHostname(container.Image, policy.Hostname) {
+			continue
+		}
+
+		if policy.Kind == backend_types.TrustPolicyDisabled {
+			return nil, nil
+		}
+
+		if !policy.HasVerifier() {
+			if policy.Mode == backend_types.TrustPolicyModeEnforce {
+				return nil, fmt.Errorf("image %q matches an enforced trust policy for %q but no verifier is configured", container.Image, policy.Hostname)
+			}
+			log.Warn().Str("image", container.Image).Str("registry", policy.Hostname).
+				Msg("trust policy has no verifier configured; continuing without verifying the image")
+			return nil, nil
+		}
+
+		return &backend_types.ImageTrust{
+			Policy:      policy.Kind,
+			PublicKey:   policy.PublicKey,
+			Identities:  policy.Identities,
+			OIDCIssuers: policy.OIDCIssuers,
+			TUFRoot:     policy.TUFRoot,
+			RekorURL:    policy.RekorURL,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// buildFor compiles a step's `build:` block into a backend_types.Build,
+// resolving registry credentials for the output image and every
+// cache_from/cache_to ref so the backend can log into each one, even when
+// they point at different registries than the output image.
+func (c *Compiler) buildFor(container *yaml_types.Container) (*backend_types.Build, error) {
+	if container.Build == nil {
+		return nil, fmt.Errorf("step %q has type build but no build block to compile", container.Name)
+	}
+	opts := container.Build
+
+	build := &backend_types.Build{
+		Context:    opts.Context,
+		Dockerfile: opts.Dockerfile,
+		Platforms:  opts.Platforms,
+		Target:     opts.Target,
+		BuildArgs:  opts.Args,
+		Secrets:    opts.Secrets,
+		SSH:        opts.SSH,
+		Output: backend_types.BuildOutput{
+			Type:  opts.Output.Type,
+			Image: opts.Output.Image,
+			Push:  opts.Output.Push,
+			Dest:  opts.Output.Dest,
+		},
+		Auths: map[string]backend_types.Auth{},
+	}
+
+	for _, cacheFrom := range opts.CacheFrom {
+		build.CacheFrom = append(build.CacheFrom, backend_types.BuildCache{Type: cacheFrom.Type, Attrs: cacheFrom.Attrs})
+		c.recordBuildAuth(build.Auths, cacheFrom.Attrs["ref"])
+	}
+	for _, cacheTo := range opts.CacheTo {
+		build.CacheTo = append(build.CacheTo, backend_types.BuildCache{Type: cacheTo.Type, Attrs: cacheTo.Attrs})
+		c.recordBuildAuth(build.Auths, cacheTo.Attrs["ref"])
+	}
+
+	if build.Output.Image != "" {
+		c.recordBuildAuth(build.Auths, build.Output.Image)
+	}
+
+	return build, nil
+}
+
+// recordBuildAuth resolves the registry credentials for ref's hostname and,
+// if one is configured, records it in auths keyed by hostname. It's called
+// once per output/cache_from/cache_to ref, since each may point at a
+// different registry requiring its own login.
+func (c *Compiler) recordBuildAuth(auths map[string]backend_types.Auth, ref string) {
+	if ref == "" {
+		return
+	}
+	for _, registry := range c.registries {
+		if utils.MatchHostname(ref, registry.Hostname) {
+			auths[registry.Hostname] = backend_types.Auth{
+				Username: registry.Username,
+				Password: registry.Password,
+				Email:    registry.Email,
+			}
+			return
+		}
+	}
+}
+
+// nodeSelectorForPlatform merges a kubernetes.io/os and kubernetes.io/arch
+// constraint derived from an "os/arch[/variant]" platform string into base,
+// leaving base untouched when platform is empty (the step isn't pinned to a
+// single build platform).
+func nodeSelectorForPlatform(base map[string]string, platform string) map[string]string {
+	if platform == "" {
+		return base
+	}
+
+	selector := map[string]string{}
+	maps.Copy(selector, base)
+
+	parts := strings.SplitN(platform, "/", 3)
+	if len(parts) >= 1 && parts[0] != "" {
+		selector["kubernetes.io/os"] = parts[0]
+	}
+	if len(parts) >= 2 && parts[1] != "" {
+		selector["kubernetes.io/arch"] = parts[1]
+	}
+
+	return selector
+}
+
+// registryMirrorsFor resolves the pull-through mirrors configured for
+// container's image, in configuration order. A mirror's own credentials are
+// kept on its Mirror entry rather than merged into the step's AuthConfig, so
+// mirror auth never leaks to the upstream registry and vice versa.
+func (c *Compiler) registryMirrorsFor(container *yaml_types.Container) []backend_types.Mirror {
+	var mirrors []backend_types.Mirror
+	for _, mirror := range c.mirrors {
+		if !matchMirrorSource(container.Image, mirror.Source) {
+			continue
+		}
+		mirrors = append(mirrors, backend_types.Mirror{
+			Host:     mirror.MirrorHost,
+			Insecure: mirror.Insecure,
+			Auth: backend_types.Auth{
+				Username: mirror.Username,
+				Password: mirror.Password,
+				Email:    mirror.Email,
+			},
+		})
+	}
+	return mirrors
+}
+
+// matchMirrorSource reports whether image's registry matches a mirror's
+// configured source, treating "*" as shorthand for docker.io.
+func matchMirrorSource(image, source string) bool {
+	if source == "*" {
+		source = ""
 	}
+	return utils.MatchHostname(image, source)
 }
 
 func (c *Compiler) stepWorkdir(container *yaml_types.Container) string {
@@ -0,0 +1,109 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	yaml_types "github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/types"
+)
+
+func TestBuildForMissingBuildBlockFails(t *testing.T) {
+	c := New()
+	container := &yaml_types.Container{Name: "build"}
+
+	if _, err := c.buildFor(container); err == nil {
+		t.Fatal("expected an error when the step has no build block")
+	}
+}
+
+func TestBuildForTranslatesCacheFromAndCacheTo(t *testing.T) {
+	c := New()
+	container := &yaml_types.Container{
+		Name: "build",
+		Build: &yaml_types.BuildOptions{
+			Context:    ".",
+			Dockerfile: "Dockerfile",
+			CacheFrom:  []yaml_types.BuildCache{{Type: "registry", Attrs: map[string]string{"ref": "registry.example.com/app:cache"}}},
+			CacheTo:    []yaml_types.BuildCache{{Type: "registry", Attrs: map[string]string{"ref": "registry.example.com/app:cache", "mode": "max"}}},
+		},
+	}
+
+	build, err := c.buildFor(container)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(build.CacheFrom) != 1 || build.CacheFrom[0].Attrs["ref"] != "registry.example.com/app:cache" {
+		t.Fatalf("expected cache_from to be translated, got %+v", build.CacheFrom)
+	}
+	if len(build.CacheTo) != 1 || build.CacheTo[0].Attrs["mode"] != "max" {
+		t.Fatalf("expected cache_to to be translated, got %+v", build.CacheTo)
+	}
+}
+
+func TestBuildForResolvesOutputRegistryAuth(t *testing.T) {
+	c := New(WithRegistry(Registry{
+		Hostname: "registry.example.com",
+		Username: "output-user",
+		Password: "output-pass",
+	}))
+	container := &yaml_types.Container{
+		Name: "build",
+		Build: &yaml_types.BuildOptions{
+			Context:    ".",
+			Dockerfile: "Dockerfile",
+			Output:     yaml_types.BuildOutput{Type: "image", Image: "registry.example.com/app:latest", Push: true},
+		},
+	}
+
+	build, err := c.buildFor(container)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	auth, ok := build.Auths["registry.example.com"]
+	if !ok || auth.Username != "output-user" {
+		t.Fatalf("expected auth for the output image's registry, got %+v", build.Auths)
+	}
+}
+
+func TestBuildForResolvesAuthForCacheRegistriesDistinctFromOutput(t *testing.T) {
+	c := New(WithRegistry(
+		Registry{Hostname: "output.example.com", Username: "output-user", Password: "output-pass"},
+	), WithRegistry(
+		Registry{Hostname: "cache.example.com", Username: "cache-user", Password: "cache-pass"},
+	))
+	container := &yaml_types.Container{
+		Name: "build",
+		Build: &yaml_types.BuildOptions{
+			Context:    ".",
+			Dockerfile: "Dockerfile",
+			CacheFrom:  []yaml_types.BuildCache{{Type: "registry", Attrs: map[string]string{"ref": "cache.example.com/app:cache"}}},
+			CacheTo:    []yaml_types.BuildCache{{Type: "registry", Attrs: map[string]string{"ref": "cache.example.com/app:cache"}}},
+			Output:     yaml_types.BuildOutput{Type: "image", Image: "output.example.com/app:latest", Push: true},
+		},
+	}
+
+	build, err := c.buildFor(container)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if auth, ok := build.Auths["cache.example.com"]; !ok || auth.Username != "cache-user" {
+		t.Fatalf("expected auth for the cache registry distinct from the output registry, got %+v", build.Auths)
+	}
+	if auth, ok := build.Auths["output.example.com"]; !ok || auth.Username != "output-user" {
+		t.Fatalf("expected auth for the output registry, got %+v", build.Auths)
+	}
+}
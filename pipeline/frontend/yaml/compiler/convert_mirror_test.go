@@ -0,0 +1,68 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	yaml_types "github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/types"
+)
+
+func TestRegistryMirrorsForMatchesWildcardDockerHub(t *testing.T) {
+	c := New(WithMirror(Mirror{Source: "*", MirrorHost: "mirror.internal:5000"}))
+
+	container := &yaml_types.Container{Image: "golang:1.21"}
+	mirrors := c.registryMirrorsFor(container)
+
+	if len(mirrors) != 1 || mirrors[0].Host != "mirror.internal:5000" {
+		t.Fatalf("expected the wildcard mirror to match a docker.io image, got %+v", mirrors)
+	}
+}
+
+func TestRegistryMirrorsForDoesNotMatchOtherRegistries(t *testing.T) {
+	c := New(WithMirror(Mirror{Source: "*", MirrorHost: "mirror.internal:5000"}))
+
+	container := &yaml_types.Container{Image: "registry.example.com/app:latest"}
+	mirrors := c.registryMirrorsFor(container)
+
+	if len(mirrors) != 0 {
+		t.Fatalf("expected the docker.io wildcard to leave other registries unmirrored, got %+v", mirrors)
+	}
+}
+
+func TestRegistryMirrorsForKeepsMirrorAuthSeparateFromRegistryAuth(t *testing.T) {
+	c := New(
+		WithRegistry(Registry{Hostname: "registry.example.com", Username: "upstream-user", Password: "upstream-pass"}),
+		WithMirror(Mirror{Source: "registry.example.com", MirrorHost: "mirror.internal:5000", Username: "mirror-user", Password: "mirror-pass"}),
+	)
+
+	container := &yaml_types.Container{Image: "registry.example.com/app:latest"}
+
+	steps, err := c.createProcess("step", container, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 1 {
+		t.Fatalf("expected a single step, got %d", len(steps))
+	}
+	step := steps[0]
+
+	if step.AuthConfig.Username != "upstream-user" {
+		t.Fatalf("expected AuthConfig to keep the upstream registry's credentials, got %+v", step.AuthConfig)
+	}
+	if len(step.RegistryMirrors) != 1 || step.RegistryMirrors[0].Auth.Username != "mirror-user" {
+		t.Fatalf("expected the mirror's own credentials on its Mirror entry, got %+v", step.RegistryMirrors)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+	yaml_types "github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/types"
+)
+
+func TestCreateStepEmitsSecretRefForExternalProvider(t *testing.T) {
+	c := New(WithSecretProvider(SecretProviderBinding{
+		Name:     "deploy_token",
+		Provider: backend_types.SecretProviderVault,
+		Path:     "secret/data/deploy",
+		Field:    "token",
+	}))
+	container := &yaml_types.Container{
+		Name: "deploy",
+		Secrets: yaml_types.Secrets{
+			Secrets: []yaml_types.SecretRequest{{Source: "deploy_token", Target: "deploy_token"}},
+		},
+	}
+
+	steps, err := c.createProcess("deploy", container, backend_types.StepTypeCommands)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	step := steps[0]
+
+	if len(step.SecretRefs) != 1 {
+		t.Fatalf("expected a single secret ref, got %+v", step.SecretRefs)
+	}
+	ref := step.SecretRefs[0]
+	if ref.Provider != backend_types.SecretProviderVault || ref.Path != "secret/data/deploy" || ref.TargetEnv != "DEPLOY_TOKEN" || !ref.Mask {
+		t.Fatalf("unexpected secret ref: %+v", ref)
+	}
+	if _, leaked := step.Environment["DEPLOY_TOKEN"]; leaked {
+		t.Fatal("expected an externally-provided secret not to be inlined into Environment")
+	}
+}
+
+func TestCreateStepInlinesBuiltInStoreSecret(t *testing.T) {
+	c := New(WithSecret(Secret{Name: "api_key", Value: "s3cr3t"}))
+	container := &yaml_types.Container{
+		Name: "deploy",
+		Secrets: yaml_types.Secrets{
+			Secrets: []yaml_types.SecretRequest{{Source: "api_key", Target: "api_key"}},
+		},
+	}
+
+	steps, err := c.createProcess("deploy", container, backend_types.StepTypeCommands)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	step := steps[0]
+
+	if step.Environment["API_KEY"] != "s3cr3t" {
+		t.Fatalf("expected the built-in-store secret to be inlined for backward compatibility, got %+v", step.Environment)
+	}
+	if len(step.MaskedValues) != 1 || step.MaskedValues[0] != "s3cr3t" {
+		t.Fatalf("expected the inlined value to be recorded for masking, got %+v", step.MaskedValues)
+	}
+	if len(step.SecretRefs) != 0 {
+		t.Fatalf("expected no SecretRefs for a built-in-store secret, got %+v", step.SecretRefs)
+	}
+}
+
+func TestCreateStepFailsWhenPluginSettingReferencesProviderBoundSecret(t *testing.T) {
+	c := New(WithSecretProvider(SecretProviderBinding{
+		Name:     "deploy_token",
+		Provider: backend_types.SecretProviderVault,
+		Path:     "secret/data/deploy",
+		Field:    "token",
+	}))
+	container := &yaml_types.Container{
+		Name:     "deploy",
+		Settings: map[string]any{"token": "Bearer ${DEPLOY_TOKEN}"},
+	}
+
+	if _, err := c.createProcess("deploy", container, backend_types.StepTypeCommands); err == nil {
+		t.Fatal("expected an error for a plugin setting referencing a provider-bound secret")
+	}
+}
+
+func TestCreateStepGatesExternalProviderBeforeConsultingIt(t *testing.T) {
+	c := New(WithSecretProvider(SecretProviderBinding{
+		Name:     "deploy_token",
+		Provider: backend_types.SecretProviderVault,
+		Path:     "secret/data/deploy",
+		Field:    "token",
+		Gate:     secretGate{PluginsOnly: true},
+	}))
+	container := &yaml_types.Container{
+		Name:     "deploy",
+		Commands: []string{"echo hi"},
+		Secrets: yaml_types.Secrets{
+			Secrets: []yaml_types.SecretRequest{{Source: "deploy_token", Target: "deploy_token"}},
+		},
+	}
+
+	steps, err := c.createProcess("deploy", container, backend_types.StepTypeCommands)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	step := steps[0]
+
+	if len(step.SecretRefs) != 0 {
+		t.Fatalf("expected a plugins-only binding to be gated out for a step with commands, got %+v", step.SecretRefs)
+	}
+}
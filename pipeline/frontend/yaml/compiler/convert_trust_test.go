@@ -0,0 +1,97 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+	yaml_types "github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/types"
+)
+
+func TestImageTrustForEnforceWithNoVerifierFails(t *testing.T) {
+	c := New(WithTrustPolicy(TrustPolicy{
+		Hostname: "registry.example.com",
+		Kind:     backend_types.TrustPolicyCosignKey,
+		Mode:     backend_types.TrustPolicyModeEnforce,
+		// PublicKey intentionally left unset: HasVerifier() must be false.
+	}))
+
+	container := &yaml_types.Container{Image: "registry.example.com/app:latest"}
+
+	_, err := c.imageTrustFor(container)
+	if err == nil {
+		t.Fatal("expected an error when an enforced policy has no verifier configured")
+	}
+}
+
+func TestImageTrustForWarnWithNoVerifierSucceeds(t *testing.T) {
+	c := New(WithTrustPolicy(TrustPolicy{
+		Hostname: "registry.example.com",
+		Kind:     backend_types.TrustPolicyCosignKey,
+		Mode:     backend_types.TrustPolicyModeWarn,
+	}))
+
+	container := &yaml_types.Container{Image: "registry.example.com/app:latest"}
+
+	trust, err := c.imageTrustFor(container)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trust != nil {
+		t.Fatalf("expected no trust requirement when the policy can't be satisfied in warn mode, got %+v", trust)
+	}
+}
+
+func TestImageTrustForResolvesConfiguredVerifier(t *testing.T) {
+	c := New(WithTrustPolicy(TrustPolicy{
+		Hostname:  "registry.example.com",
+		Kind:      backend_types.TrustPolicyCosignKey,
+		Mode:      backend_types.TrustPolicyModeEnforce,
+		PublicKey: "-----BEGIN PUBLIC KEY-----",
+	}))
+
+	container := &yaml_types.Container{Image: "registry.example.com/app:latest"}
+
+	trust, err := c.imageTrustFor(container)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trust == nil || trust.Policy != backend_types.TrustPolicyCosignKey {
+		t.Fatalf("expected a resolved cosign-key trust requirement, got %+v", trust)
+	}
+}
+
+func TestImageTrustForStepOptOut(t *testing.T) {
+	c := New(WithTrustPolicy(TrustPolicy{
+		Hostname:  "registry.example.com",
+		Kind:      backend_types.TrustPolicyCosignKey,
+		Mode:      backend_types.TrustPolicyModeEnforce,
+		PublicKey: "-----BEGIN PUBLIC KEY-----",
+	}))
+
+	container := &yaml_types.Container{
+		Image:  "registry.example.com/app:latest",
+		Verify: &yaml_types.VerifyBlock{Disabled: true},
+	}
+
+	trust, err := c.imageTrustFor(container)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trust != nil {
+		t.Fatalf("expected a step opt-out to bypass trust policy matching, got %+v", trust)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+	"github.com/woodpecker-ci/woodpecker/pipeline/frontend/metadata"
+)
+
+// FanOutFailed reports whether siblings — the Steps a single multi-platform
+// step was fanned out into — should be treated as that logical step having
+// failed: true if failed reports true for any of them, unless the step opted
+// into `failure: ignore`, in which case a single platform's failure doesn't
+// fail the logical step.
+func FanOutFailed(siblings []*backend_types.Step, failed map[string]bool) bool {
+	for _, step := range siblings {
+		if !failed[step.UUID] {
+			continue
+		}
+		if step.Failure == string(metadata.FailureIgnore) {
+			continue
+		}
+		return true
+	}
+	return false
+}
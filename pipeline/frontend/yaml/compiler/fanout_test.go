@@ -0,0 +1,101 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	"testing"
+
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+	yaml_types "github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/types"
+)
+
+func TestCreateProcessFansOutOverDeclaredPlatforms(t *testing.T) {
+	c := New(WithPrefix("ci"))
+	container := &yaml_types.Container{
+		Name:      "build",
+		Image:     "golang:1.21",
+		Platforms: []string{"linux/amd64", "linux/arm64"},
+	}
+
+	steps, err := c.createProcess("build", container, backend_types.StepTypeCommands)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("expected one step per platform, got %d", len(steps))
+	}
+	if steps[0].FanOutGroup == "" || steps[0].FanOutGroup != steps[1].FanOutGroup {
+		t.Fatalf("expected siblings to share a FanOutGroup, got %+v", steps)
+	}
+	if steps[0].Platform != "linux/amd64" || steps[1].Platform != "linux/arm64" {
+		t.Fatalf("expected each sibling to carry its own platform, got %+v", steps)
+	}
+	if steps[0].Alias == steps[1].Alias {
+		t.Fatalf("expected distinct aliases for each sibling, got %q twice", steps[0].Alias)
+	}
+	if steps[0].BackendOptions.Kubernetes.NodeSelector["kubernetes.io/arch"] != "amd64" {
+		t.Fatalf("expected the NodeSelector to reflect the platform, got %+v", steps[0].BackendOptions.Kubernetes.NodeSelector)
+	}
+}
+
+func TestCreateProcessAppliesWorkflowPlatformDefault(t *testing.T) {
+	c := New(WithPlatforms([]string{"linux/amd64", "linux/arm64"}))
+	container := &yaml_types.Container{Name: "build", Image: "golang:1.21"}
+
+	steps, err := c.createProcess("build", container, backend_types.StepTypeCommands)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("expected the workflow default to fan the step out, got %d steps", len(steps))
+	}
+}
+
+func TestCreateProcessDoesNotFanOutServices(t *testing.T) {
+	c := New(WithPlatforms([]string{"linux/amd64", "linux/arm64"}))
+	container := &yaml_types.Container{Name: "database", Image: "postgres:15"}
+
+	steps, err := c.createProcess("database", container, backend_types.StepTypeService)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 1 {
+		t.Fatalf("expected a service to never fan out, got %d steps", len(steps))
+	}
+}
+
+func TestFanOutFailedFailsWhenAnySiblingFails(t *testing.T) {
+	siblings := []*backend_types.Step{
+		{UUID: "a"},
+		{UUID: "b"},
+	}
+	failed := map[string]bool{"b": true}
+
+	if !FanOutFailed(siblings, failed) {
+		t.Fatal("expected a single failing sibling to fail the logical step")
+	}
+}
+
+func TestFanOutFailedIgnoresFailureWhenStepOptsOut(t *testing.T) {
+	siblings := []*backend_types.Step{
+		{UUID: "a", Failure: "ignore"},
+		{UUID: "b", Failure: "ignore"},
+	}
+	failed := map[string]bool{"a": true}
+
+	if FanOutFailed(siblings, failed) {
+		t.Fatal("expected failure: ignore to stop a single platform's failure from failing the logical step")
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compiler
+
+import (
+	backend_types "github.com/woodpecker-ci/woodpecker/pipeline/backend/types"
+	yaml_types "github.com/woodpecker-ci/woodpecker/pipeline/frontend/yaml/types"
+)
+
+// SecretProviderBinding binds a secret name a step may request to an
+// external SecretProvider, resolved by path/field at step-execution time
+// instead of being materialized into Environment at compile time.
+type SecretProviderBinding struct {
+	Name     string
+	Provider backend_types.SecretProviderKind
+	Path     string
+	Field    string
+
+	Gate secretGate
+}
+
+// Available reports whether the binding may be used by container, honoring
+// the same `available:` gating a built-in-store Secret is registered with.
+func (b SecretProviderBinding) Available(container *yaml_types.Container) bool {
+	return b.Gate.available(container)
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package settings turns a plugin step's `settings:` block into the
+// PLUGIN_-prefixed environment variables the plugin reads at runtime.
+package settings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParamsToEnv flattens settings into PLUGIN_<KEY>=<value> entries in
+// environment, substituting any `${SECRET_NAME}` reference against secrets.
+// unresolved names a secret that exists but can't be substituted at compile
+// time (one bound to an external provider, resolved only at step-execution
+// time); referencing one of those in a setting is a compile error, not a
+// silently-unsubstituted placeholder.
+func ParamsToEnv(settingsMap map[string]any, environment map[string]string, secrets map[string]string, unresolved map[string]bool) error {
+	for key, value := range settingsMap {
+		rendered, err := renderValue(value)
+		if err != nil {
+			return fmt.Errorf("setting %q: %w", key, err)
+		}
+
+		for name := range unresolved {
+			placeholder := fmt.Sprintf("${%s}", strings.ToUpper(name))
+			if strings.Contains(rendered, placeholder) {
+				return fmt.Errorf("setting %q references secret %q, which is bound to an external provider and can't be resolved until step-execution time", key, name)
+			}
+		}
+
+		for name, secret := range secrets {
+			placeholder := fmt.Sprintf("${%s}", strings.ToUpper(name))
+			rendered = strings.ReplaceAll(rendered, placeholder, secret)
+		}
+
+		envKey := "PLUGIN_" + strings.ToUpper(key)
+		environment[envKey] = rendered
+	}
+	return nil
+}
+
+func renderValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
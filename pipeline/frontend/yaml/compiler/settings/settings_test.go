@@ -0,0 +1,49 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import "testing"
+
+func TestParamsToEnvSubstitutesSecretPlaceholder(t *testing.T) {
+	environment := map[string]string{}
+	err := ParamsToEnv(
+		map[string]any{"token": "Bearer ${API_KEY}"},
+		environment,
+		map[string]string{"api_key": "s3cr3t"},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if environment["PLUGIN_TOKEN"] != "Bearer s3cr3t" {
+		t.Fatalf("expected the placeholder to be substituted, got %q", environment["PLUGIN_TOKEN"])
+	}
+}
+
+func TestParamsToEnvFailsForUnresolvedProviderSecret(t *testing.T) {
+	environment := map[string]string{}
+	err := ParamsToEnv(
+		map[string]any{"token": "Bearer ${DEPLOY_TOKEN}"},
+		environment,
+		map[string]string{},
+		map[string]bool{"deploy_token": true},
+	)
+	if err == nil {
+		t.Fatal("expected an error for a setting referencing a provider-bound secret")
+	}
+	if _, ok := environment["PLUGIN_TOKEN"]; ok {
+		t.Fatalf("expected no placeholder to be left unsubstituted in environment, got %+v", environment)
+	}
+}
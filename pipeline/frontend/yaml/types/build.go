@@ -0,0 +1,43 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// BuildOptions is a step's `build:` block, compiled into a backend_types.Build.
+type BuildOptions struct {
+	Context    string            `yaml:"context,omitempty"`
+	Dockerfile string            `yaml:"dockerfile,omitempty"`
+	Platforms  []string          `yaml:"platforms,omitempty"`
+	Target     string            `yaml:"target,omitempty"`
+	Args       map[string]string `yaml:"build_args,omitempty"`
+	Secrets    []string          `yaml:"secrets,omitempty"`
+	SSH        []string          `yaml:"ssh,omitempty"`
+	CacheFrom  []BuildCache      `yaml:"cache_from,omitempty"`
+	CacheTo    []BuildCache      `yaml:"cache_to,omitempty"`
+	Output     BuildOutput       `yaml:"output,omitempty"`
+}
+
+// BuildCache is one `cache_from`/`cache_to` entry of a `build:` block.
+type BuildCache struct {
+	Type  string            `yaml:"type"`
+	Attrs map[string]string `yaml:"attrs,omitempty"`
+}
+
+// BuildOutput is a `build:` block's `output:` entry.
+type BuildOutput struct {
+	Type  string `yaml:"type"`
+	Image string `yaml:"image,omitempty"`
+	Push  bool   `yaml:"push,omitempty"`
+	Dest  string `yaml:"dest,omitempty"`
+}
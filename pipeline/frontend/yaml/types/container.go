@@ -0,0 +1,172 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package types holds the structures the YAML parser decodes a workflow's
+// `steps:`/`services:` blocks into, before the compiler translates them into
+// backend_types.Step values.
+package types
+
+import "strings"
+
+// Container represents a step, service, or plugin as written in the YAML,
+// prior to compilation into a backend_types.Step.
+type Container struct {
+	Name           string
+	Image          string
+	Pull           bool
+	Privileged     bool
+	NetworkMode    string
+	IpcMode        string
+	Detached       bool
+	Environment    map[string]string
+	Commands       []string
+	ExtraHosts     []string
+	Volumes        Volumes
+	Tmpfs          []string
+	Devices        []string
+	DNS            []string
+	DNSSearch      []string
+	MemSwapLimit   int64
+	MemLimit       int64
+	ShmSize        int64
+	Sysctls        map[string]string
+	CPUQuota       int64
+	CPUShares      int64
+	CPUSet         string
+	Directory      string
+	Settings       map[string]any
+	Secrets        Secrets
+	BackendOptions BackendOptions
+	When           WhenConditions
+	Failure        string
+
+	// Verify holds the step's `verify:` image-trust opt-out, if set.
+	Verify *VerifyBlock
+
+	// Build holds the step's `build:` block, if set. A step with Build set
+	// compiles to a backend_types.StepTypeBuild step instead of running Image.
+	Build *BuildOptions
+
+	// Platforms lists the "os/arch[/variant]" platforms this step runs on. A
+	// step declaring more than one is fanned out into one backend_types.Step
+	// per platform. Empty means the workflow's top-level `platforms:` default
+	// applies instead.
+	Platforms []string
+}
+
+// IsPlugin reports whether the container runs as a plugin, i.e. it has no
+// explicit commands and is driven entirely by Settings.
+func (c *Container) IsPlugin() bool {
+	return len(c.Commands) == 0
+}
+
+// Volumes holds the volume mounts declared on a step.
+type Volumes struct {
+	Volumes []Volume
+}
+
+// Volume is a single `source:target[:mode]` volume mount.
+type Volume struct {
+	Source string
+	Target string
+	Mode   string
+}
+
+// String renders the volume the way the docker backend expects it on the CLI.
+func (v Volume) String() string {
+	parts := []string{v.Source, v.Target}
+	if v.Mode != "" {
+		parts = append(parts, v.Mode)
+	}
+	return strings.Join(parts, ":")
+}
+
+// Secrets holds the `secrets:` list requested by a step.
+type Secrets struct {
+	Secrets []SecretRequest
+}
+
+// SecretRequest binds a named secret to an environment variable for a step.
+type SecretRequest struct {
+	Source string
+	Target string
+}
+
+// BackendOptions holds the advanced, backend-specific settings a step may set.
+type BackendOptions struct {
+	Kubernetes KubernetesBackendOptions
+}
+
+// KubernetesBackendOptions are the Kubernetes-specific advanced settings a
+// step can set under `backend_options.kubernetes:`.
+type KubernetesBackendOptions struct {
+	Resources          Resources
+	ServiceAccountName string
+	NodeSelector       map[string]string
+	Tolerations        []Toleration
+}
+
+// Resources mirrors the Kubernetes resource requests/limits syntax.
+type Resources struct {
+	Limits   map[string]string
+	Requests map[string]string
+}
+
+// Toleration mirrors a Kubernetes pod toleration.
+type Toleration struct {
+	Key               string
+	Operator          string
+	Value             string
+	Effect            string
+	TolerationSeconds *int64
+}
+
+// WhenConditions is the parsed `when:` block controlling if/when a step runs.
+type WhenConditions struct {
+	Constraints []WhenConstraint
+}
+
+// WhenConstraint is a single entry of a `when:` block.
+type WhenConstraint struct {
+	Status []string
+}
+
+// IncludesStatusSuccess reports whether the step should run on a successful
+// pipeline so far: true when at least one constraint lists "success", or when
+// no constraint sets a status at all.
+func (w WhenConditions) IncludesStatusSuccess() bool {
+	return w.includesStatus("success", true)
+}
+
+// IncludesStatusFailure reports whether the step should run after a failure:
+// true only when a constraint explicitly lists "failure".
+func (w WhenConditions) IncludesStatusFailure() bool {
+	return w.includesStatus("failure", false)
+}
+
+func (w WhenConditions) includesStatus(status string, defaultIfUnset bool) bool {
+	sawStatus := false
+	for _, c := range w.Constraints {
+		for _, s := range c.Status {
+			sawStatus = true
+			if s == status {
+				return true
+			}
+		}
+	}
+	if !sawStatus {
+		return defaultIfUnset
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// VerifyBlock is the YAML `verify:` surface on a step, letting a pipeline
+// opt individual steps out of an otherwise-applicable image trust policy.
+//
+//	steps:
+//	  build:
+//	    image: my-registry.example.com/app
+//	    verify:
+//	      disabled: true
+type VerifyBlock struct {
+	// Disabled skips trust verification for this step's image even if the
+	// matching registry has a trust policy configured.
+	Disabled bool `yaml:"disabled"`
+}
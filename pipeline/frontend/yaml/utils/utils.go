@@ -0,0 +1,92 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package utils holds small helpers shared across the yaml frontend that don't
+// belong to any single compiler stage.
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// defaultRegistry is the hostname Docker treats an image as belonging to when
+// it carries no explicit registry component (e.g. "golang:1.21" or "library/golang").
+const defaultRegistry = "docker.io"
+
+// ImageHostname returns the registry hostname an image reference resolves
+// against. An image with no explicit registry component is implicitly a
+// docker.io image, just as the Docker CLI treats it.
+func ImageHostname(image string) string {
+	image = trimTag(image)
+
+	firstSlash := strings.Index(image, "/")
+	if firstSlash == -1 {
+		return defaultRegistry
+	}
+
+	candidate := image[:firstSlash]
+	// A registry hostname contains a "." or ":" (port), or is "localhost".
+	// Anything else ("library", "myorg") is a path component on docker.io.
+	if candidate == "localhost" || strings.ContainsAny(candidate, ".:") {
+		return candidate
+	}
+	return defaultRegistry
+}
+
+// MatchHostname reports whether image's registry hostname matches hostname.
+// An empty hostname matches the default docker.io registry, mirroring how
+// Compiler.registries entries with no explicit host apply to Docker Hub images.
+func MatchHostname(image, hostname string) bool {
+	if hostname == "" {
+		hostname = defaultRegistry
+	}
+	return ImageHostname(image) == hostname
+}
+
+// MatchImage reports whether image matches any of the given patterns. A
+// pattern may be an exact "repo:tag" image reference, a bare repository
+// (matching any tag), or a path.Match glob such as "my-org/*".
+func MatchImage(image string, patterns ...string) bool {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if pattern == image {
+			return true
+		}
+		if pattern == trimTag(image) {
+			return true
+		}
+		if ok, _ := path.Match(pattern, image); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// trimTag strips a trailing ":tag" or "@digest" from an image reference,
+// leaving the bare repository path.
+func trimTag(image string) string {
+	if at := strings.Index(image, "@"); at != -1 {
+		image = image[:at]
+	}
+	// A ':' after the last '/' is a tag; a ':' before it is a registry port
+	// (e.g. "localhost:5000/app").
+	lastSlash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > lastSlash {
+		image = image[:colon]
+	}
+	return image
+}
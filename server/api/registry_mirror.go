@@ -0,0 +1,94 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package api holds the server's admin HTTP handlers.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RegistryMirror is the admin API's representation of a single pull-through
+// mirror entry.
+type RegistryMirror struct {
+	Source   string `json:"source"`
+	Mirror   string `json:"mirror"`
+	Insecure bool   `json:"insecure"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// RegistryMirrorStore persists the server's configured registry mirrors.
+type RegistryMirrorStore interface {
+	ListRegistryMirrors() ([]RegistryMirror, error)
+	SetRegistryMirrors([]RegistryMirror) error
+}
+
+// RegistryMirrorHandler serves the `registry-mirror` admin API: GET lists the
+// configured mirrors, PUT replaces the whole set, so operators running
+// bandwidth-constrained clusters can point pipelines at a local pull-through
+// cache without editing every pipeline.
+//
+// RegistryMirrorHandler does no authentication or authorization of its own —
+// since it both stores and serves registry credentials, the caller MUST
+// mount it behind an admin-only auth middleware, the same way every other
+// credential-bearing admin route in this server is gated.
+func RegistryMirrorHandler(store RegistryMirrorStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			mirrors, err := store.ListRegistryMirrors()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, redactPasswords(mirrors))
+
+		case http.MethodPut:
+			var mirrors []RegistryMirror
+			if err := json.NewDecoder(r.Body).Decode(&mirrors); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := store.SetRegistryMirrors(mirrors); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, redactPasswords(mirrors))
+
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// redactPasswords returns a copy of mirrors with Password cleared. Password
+// is write-only, like every other credential-bearing field in this
+// codebase: a client may set it via PUT, but never reads it back.
+func redactPasswords(mirrors []RegistryMirror) []RegistryMirror {
+	redacted := make([]RegistryMirror, len(mirrors))
+	for i, mirror := range mirrors {
+		redacted[i] = mirror
+		redacted[i].Password = ""
+	}
+	return redacted
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type memoryRegistryMirrorStore struct {
+	mirrors []RegistryMirror
+}
+
+func (s *memoryRegistryMirrorStore) ListRegistryMirrors() ([]RegistryMirror, error) {
+	return s.mirrors, nil
+}
+
+func (s *memoryRegistryMirrorStore) SetRegistryMirrors(mirrors []RegistryMirror) error {
+	s.mirrors = mirrors
+	return nil
+}
+
+func TestRegistryMirrorHandlerListsConfiguredMirrors(t *testing.T) {
+	store := &memoryRegistryMirrorStore{mirrors: []RegistryMirror{{Source: "*", Mirror: "mirror.internal:5000"}}}
+	handler := RegistryMirrorHandler(store)
+
+	req := httptest.NewRequest(http.MethodGet, "/registry-mirror", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var got []RegistryMirror
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(got) != 1 || got[0].Mirror != "mirror.internal:5000" {
+		t.Fatalf("unexpected mirrors in response: %+v", got)
+	}
+}
+
+func TestRegistryMirrorHandlerRedactsPasswordFromListResponse(t *testing.T) {
+	store := &memoryRegistryMirrorStore{mirrors: []RegistryMirror{
+		{Source: "*", Mirror: "mirror.internal:5000", Username: "mirror-user", Password: "s3cr3t"},
+	}}
+	handler := RegistryMirrorHandler(store)
+
+	req := httptest.NewRequest(http.MethodGet, "/registry-mirror", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if strings.Contains(rec.Body.String(), "s3cr3t") {
+		t.Fatalf("expected the password to be redacted from the response, got %s", rec.Body.String())
+	}
+
+	var got []RegistryMirror
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(got) != 1 || got[0].Password != "" || got[0].Username != "mirror-user" {
+		t.Fatalf("expected a redacted password alongside the rest of the entry, got %+v", got)
+	}
+	if store.mirrors[0].Password != "s3cr3t" {
+		t.Fatalf("expected the store's own copy of the password to be untouched, got %+v", store.mirrors[0])
+	}
+}
+
+func TestRegistryMirrorHandlerReplacesMirrorsOnPut(t *testing.T) {
+	store := &memoryRegistryMirrorStore{}
+	handler := RegistryMirrorHandler(store)
+
+	body, _ := json.Marshal([]RegistryMirror{{Source: "registry.example.com", Mirror: "mirror.internal:5000"}})
+	req := httptest.NewRequest(http.MethodPut, "/registry-mirror", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if len(store.mirrors) != 1 || store.mirrors[0].Source != "registry.example.com" {
+		t.Fatalf("expected the store to be updated, got %+v", store.mirrors)
+	}
+}
+
+func TestRegistryMirrorHandlerRedactsPasswordFromPutResponse(t *testing.T) {
+	store := &memoryRegistryMirrorStore{}
+	handler := RegistryMirrorHandler(store)
+
+	body, _ := json.Marshal([]RegistryMirror{{Source: "registry.example.com", Mirror: "mirror.internal:5000", Password: "s3cr3t"}})
+	req := httptest.NewRequest(http.MethodPut, "/registry-mirror", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if strings.Contains(rec.Body.String(), "s3cr3t") {
+		t.Fatalf("expected the password to be redacted from the response, got %s", rec.Body.String())
+	}
+	if store.mirrors[0].Password != "s3cr3t" {
+		t.Fatalf("expected the stored password to still be persisted, got %+v", store.mirrors[0])
+	}
+}
+
+func TestRegistryMirrorHandlerRejectsUnsupportedMethods(t *testing.T) {
+	store := &memoryRegistryMirrorStore{}
+	handler := RegistryMirrorHandler(store)
+
+	req := httptest.NewRequest(http.MethodDelete, "/registry-mirror", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
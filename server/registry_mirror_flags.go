@@ -0,0 +1,92 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server holds the server-side configuration that feeds the
+// compiler: parsing CLI flags and serving the admin APIs operators use to
+// manage them.
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RegistryMirror is a pull-through mirror entry parsed from a
+// `--registry-mirror` flag, ready to be turned into a compiler.Mirror.
+type RegistryMirror struct {
+	Source   string
+	Mirror   string
+	Insecure bool
+	Username string
+	Password string
+	Email    string
+}
+
+// ParseRegistryMirrors parses the repeatable `--registry-mirror` flag's
+// values. Each value is a semicolon-separated list of key=value pairs, e.g.
+// "source=*;mirror=mirror.internal:5000;insecure".
+func ParseRegistryMirrors(values []string) ([]RegistryMirror, error) {
+	mirrors := make([]RegistryMirror, 0, len(values))
+	for _, value := range values {
+		mirror, err := parseRegistryMirror(value)
+		if err != nil {
+			return nil, err
+		}
+		mirrors = append(mirrors, mirror)
+	}
+	return mirrors, nil
+}
+
+func parseRegistryMirror(value string) (RegistryMirror, error) {
+	var mirror RegistryMirror
+
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if part == "insecure" {
+			mirror.Insecure = true
+			continue
+		}
+
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return RegistryMirror{}, fmt.Errorf("invalid registry-mirror entry %q: expected key=value", part)
+		}
+		switch key {
+		case "source":
+			mirror.Source = val
+		case "mirror":
+			mirror.Mirror = val
+		case "username":
+			mirror.Username = val
+		case "password":
+			mirror.Password = val
+		case "email":
+			mirror.Email = val
+		default:
+			return RegistryMirror{}, fmt.Errorf("invalid registry-mirror entry %q: unknown key %q", part, key)
+		}
+	}
+
+	if mirror.Source == "" {
+		return RegistryMirror{}, fmt.Errorf("registry-mirror entry %q: missing source", value)
+	}
+	if mirror.Mirror == "" {
+		return RegistryMirror{}, fmt.Errorf("registry-mirror entry %q: missing mirror", value)
+	}
+
+	return mirror, nil
+}
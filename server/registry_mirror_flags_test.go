@@ -0,0 +1,49 @@
+// Copyright 2023 Woodpecker Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import "testing"
+
+func TestParseRegistryMirrorsParsesFields(t *testing.T) {
+	mirrors, err := ParseRegistryMirrors([]string{
+		"source=*;mirror=mirror.internal:5000;insecure;username=user;password=pass",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mirrors) != 1 {
+		t.Fatalf("expected a single mirror, got %d", len(mirrors))
+	}
+
+	got := mirrors[0]
+	if got.Source != "*" || got.Mirror != "mirror.internal:5000" || !got.Insecure || got.Username != "user" || got.Password != "pass" {
+		t.Fatalf("unexpected parsed mirror: %+v", got)
+	}
+}
+
+func TestParseRegistryMirrorsRequiresSourceAndMirror(t *testing.T) {
+	if _, err := ParseRegistryMirrors([]string{"insecure"}); err == nil {
+		t.Fatal("expected an error for an entry missing source and mirror")
+	}
+	if _, err := ParseRegistryMirrors([]string{"source=*"}); err == nil {
+		t.Fatal("expected an error for an entry missing mirror")
+	}
+}
+
+func TestParseRegistryMirrorsRejectsUnknownKeys(t *testing.T) {
+	if _, err := ParseRegistryMirrors([]string{"source=*;mirror=m;bogus=1"}); err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+}